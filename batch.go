@@ -0,0 +1,43 @@
+package blockchain
+
+import "github.com/hexablock/blockchain/stores"
+
+// chunkSize is the number of write steps a chunkedBatch commits at once
+const chunkSize = 1024
+
+// chunkedBatch is a batch that is committed and replaced by a new one every
+// chunkSize steps so that large rewrites do not exceed the size of a single
+// store transaction.  The writes are only atomic within each chunk
+type chunkedBatch struct {
+	batcher Batcher
+	batch   stores.Batch
+	n       int
+}
+
+func (bc *Blockchain) newChunkedBatch() *chunkedBatch {
+	return &chunkedBatch{batcher: bc.batcher, batch: bc.batcher.NewBatch()}
+}
+
+// step marks the end of a group of writes that must be committed together.
+// The batch is committed once chunkSize groups have been written to it
+func (cb *chunkedBatch) step() error {
+	if cb.n++; cb.n < chunkSize {
+		return nil
+	}
+
+	if err := cb.batch.Commit(); err != nil {
+		return err
+	}
+	cb.batch, cb.n = cb.batcher.NewBatch(), 0
+	return nil
+}
+
+// commit commits the writes of the current chunk
+func (cb *chunkedBatch) commit() error {
+	return cb.batch.Commit()
+}
+
+// discard drops the writes of the current chunk
+func (cb *chunkedBatch) discard() {
+	cb.batch.Discard()
+}
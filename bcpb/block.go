@@ -186,6 +186,11 @@ func (blk *Block) SetTxs(txns []*Tx, hf hasher.Hasher) {
 	}
 }
 
+// TxProof returns the merkle inclusion proof of the tx against the header root
+func (blk *Block) TxProof(txid Digest) (*MerkleProof, error) {
+	return Digests(blk.Txs).Proof(txid)
+}
+
 // Clone clones the block
 func (blk *Block) Clone() *Block {
 	hdr := *blk.Header
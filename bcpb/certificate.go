@@ -0,0 +1,45 @@
+package bcpb
+
+import "github.com/hexablock/hasher"
+
+// commitDomain prefixes the block digest when signing a commit so a block
+// signature can never be used as a commit signature
+const commitDomain = "commit:"
+
+// NewCommitCertificate returns an empty certificate for the block
+func NewCommitCertificate(blk *Block) *CommitCertificate {
+	return &CommitCertificate{
+		Block:      blk.Digest.Copy(),
+		Signatures: make([][]byte, len(blk.Header.Signers)),
+	}
+}
+
+// CommitDigest returns the digest signers sign to commit the block
+func CommitDigest(h hasher.Hasher, block Digest) Digest {
+	hf := h.New()
+	hf.Write([]byte(commitDomain))
+	hf.Write(block)
+	return NewDigest(h.Name(), hf.Sum(nil))
+}
+
+// Sign sets the commit signature of the signer with the public key.  The header
+// must be that of the certified block
+func (cert *CommitCertificate) Sign(header *BlockHeader, pubkey PublicKey, signature []byte) error {
+	i := header.SignerIndex(pubkey)
+	if i < 0 {
+		return ErrSignerNotInBlock
+	}
+
+	if len(cert.Signatures) < len(header.Signers) {
+		sigs := make([][]byte, len(header.Signers))
+		copy(sigs, cert.Signatures)
+		cert.Signatures = sigs
+	}
+
+	if len(cert.Signatures[i]) != 0 {
+		return ErrSignerAlreadySigned
+	}
+
+	cert.Signatures[i] = signature
+	return nil
+}
@@ -0,0 +1,38 @@
+package bcpb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/hasher"
+)
+
+func Test_CommitCertificate(t *testing.T) {
+	h := hasher.Default()
+
+	blk := NewBlock()
+	blk.SetSigners(PublicKey("key1"), PublicKey("key2"))
+	blk.SetHash(h)
+
+	cd := CommitDigest(h, blk.Digest)
+	assert.NotEqual(t, blk.Digest, cd)
+	assert.Equal(t, h.Name(), cd.Algorithm())
+
+	cert := NewCommitCertificate(blk)
+	assert.Equal(t, ErrSignerNotInBlock, cert.Sign(blk.Header, PublicKey("key3"), []byte("sig")))
+	assert.Nil(t, cert.Sign(blk.Header, PublicKey("key2"), []byte("sig2")))
+	assert.Equal(t, ErrSignerAlreadySigned, cert.Sign(blk.Header, PublicKey("key2"), []byte("sig2")))
+
+	b, err := cert.Marshal()
+	assert.Nil(t, err)
+
+	var cert2 CommitCertificate
+	assert.Nil(t, cert2.Unmarshal(b))
+	assert.Equal(t, blk.Digest, cert2.Block)
+	assert.Equal(t, 2, len(cert2.Signatures))
+	assert.Equal(t, 0, len(cert2.Signatures[0]))
+	assert.Equal(t, []byte("sig2"), cert2.Signatures[1])
+
+	assert.NotNil(t, cert2.Unmarshal(b[:len(b)-1]))
+}
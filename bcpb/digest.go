@@ -71,22 +71,7 @@ func ParseDigest(str string) (Digest, error) {
 // Digests is a list of digests of the same hash function
 type Digests []Digest
 
-// Root calculates the root hash of the transactions  TODO: merkle tree
+// Root calculates the merkle root of the digests.  See MerkleRoot
 func (digests Digests) Root() (Digest, error) {
-	if digests == nil || len(digests) == 0 {
-		return nil, nil
-	}
-
-	// May need to handle this is a better way
-	h, err := hasher.New(digests[0].Algorithm())
-	if err != nil {
-		return nil, err
-	}
-
-	hf := h.New()
-	for _, l := range digests {
-		hf.Write(l)
-	}
-	sh := hf.Sum(nil)
-	return NewDigest(h.Name(), sh), nil
+	return MerkleRoot(digests)
 }
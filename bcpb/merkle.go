@@ -0,0 +1,193 @@
+package bcpb
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/hexablock/hasher"
+)
+
+// Domain separation prefixes preventing a leaf from being passed off as an
+// inner node and vice versa
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+var (
+	// ErrNotInTree is returned when requesting a proof for a digest that is
+	// not a leaf of the tree
+	ErrNotInTree = errors.New("digest not in tree")
+	// ErrInvalidLeaf is returned when a leaf is not a valid digest or uses a
+	// different hash function than the other leaves
+	ErrInvalidLeaf = errors.New("invalid merkle leaf")
+)
+
+// MerkleProof is an inclusion proof for a single leaf of a merkle tree.  The
+// position of the leaf is part of the proof so a leaf can only be proven at
+// the index it was included at
+type MerkleProof struct {
+	// Index of the leaf being proven
+	Index int
+	// Total number of leaves in the tree
+	Total int
+	// Sibling hashes from the leaf level up to the root.  Levels where the
+	// node was promoted have no sibling
+	Hashes [][]byte
+}
+
+// MerkleRoot returns the merkle root of the given leaves using the hash
+// function of the first leaf.  Leaves are hashed as H(0x00 || leaf) and inner
+// nodes as H(0x01 || left || right).  When a level has an odd number of nodes
+// the last node is promoted to the next level as is, rather than being paired
+// with itself.  A nil root is returned if there are no leaves
+func MerkleRoot(leaves []Digest) (Digest, error) {
+	if len(leaves) == 0 {
+		return nil, nil
+	}
+
+	h, err := leavesHasher(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	level := merkleLeaves(h, leaves)
+	for len(level) > 1 {
+		level = merkleLevel(h, level)
+	}
+
+	return NewDigest(h.Name(), level[0]), nil
+}
+
+// NewMerkleProof returns the inclusion proof for the leaf at the given index
+func NewMerkleProof(leaves []Digest, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, ErrNotInTree
+	}
+
+	h, err := leavesHasher(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &MerkleProof{Index: index, Total: len(leaves), Hashes: make([][]byte, 0)}
+
+	level := merkleLeaves(h, leaves)
+	for i := index; len(level) > 1; i /= 2 {
+		if sib := i ^ 1; sib < len(level) {
+			proof.Hashes = append(proof.Hashes, level[sib])
+		}
+		level = merkleLevel(h, level)
+	}
+
+	return proof, nil
+}
+
+// VerifyProof returns true if the proof shows the digest is a leaf of the tree
+// with the given root
+func VerifyProof(root, digest Digest, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.Total {
+		return false
+	}
+
+	if bytes.IndexByte(root, ':') < 1 {
+		return false
+	}
+
+	h, err := hasher.New(root.Algorithm())
+	if err != nil {
+		return false
+	}
+
+	var (
+		node = merkleLeaf(h, digest)
+		used int
+	)
+
+	for i, n := proof.Index, proof.Total; n > 1; i, n = i/2, (n+1)/2 {
+		// Promoted node
+		if i^1 >= n {
+			continue
+		}
+
+		if used == len(proof.Hashes) {
+			return false
+		}
+
+		if i%2 == 0 {
+			node = merkleNode(h, node, proof.Hashes[used])
+		} else {
+			node = merkleNode(h, proof.Hashes[used], node)
+		}
+		used++
+	}
+
+	return used == len(proof.Hashes) && bytes.Equal(node, root.Hash())
+}
+
+// Proof returns the merkle inclusion proof for the given digest
+func (digests Digests) Proof(digest Digest) (*MerkleProof, error) {
+	for i := range digests {
+		if digests[i].Equal(digest) {
+			return NewMerkleProof(digests, i)
+		}
+	}
+	return nil, ErrNotInTree
+}
+
+// leavesHasher returns the hash function of the leaves.  Each leaf must be a
+// digest of the same hash function.  Leaves come from untrusted blocks so they
+// are checked before their algorithm is read
+func leavesHasher(leaves []Digest) (hasher.Hasher, error) {
+	var algo []byte
+	for _, leaf := range leaves {
+		i := bytes.IndexByte(leaf, ':')
+		if i < 1 {
+			return nil, ErrInvalidLeaf
+		}
+
+		if algo == nil {
+			algo = leaf[:i]
+		} else if !bytes.Equal(algo, leaf[:i]) {
+			return nil, ErrInvalidLeaf
+		}
+	}
+
+	return hasher.New(string(algo))
+}
+
+func merkleLeaves(h hasher.Hasher, leaves []Digest) [][]byte {
+	level := make([][]byte, len(leaves))
+	for i := range leaves {
+		level[i] = merkleLeaf(h, leaves[i])
+	}
+	return level
+}
+
+// merkleLevel returns the parent level of the given nodes
+func merkleLevel(h hasher.Hasher, nodes [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(nodes)+1)/2)
+	for i := 0; i < len(nodes); i += 2 {
+		if i+1 == len(nodes) {
+			next = append(next, nodes[i])
+			break
+		}
+		next = append(next, merkleNode(h, nodes[i], nodes[i+1]))
+	}
+	return next
+}
+
+func merkleLeaf(h hasher.Hasher, leaf []byte) []byte {
+	hf := h.New()
+	hf.Write([]byte{merkleLeafPrefix})
+	hf.Write(leaf)
+	return hf.Sum(nil)
+}
+
+func merkleNode(h hasher.Hasher, left, right []byte) []byte {
+	hf := h.New()
+	hf.Write([]byte{merkleNodePrefix})
+	hf.Write(left)
+	hf.Write(right)
+	return hf.Sum(nil)
+}
@@ -0,0 +1,117 @@
+package bcpb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/hasher"
+)
+
+func testLeaves(h hasher.Hasher, n int) Digests {
+	leaves := make(Digests, n)
+	for i := range leaves {
+		hf := h.New()
+		fmt.Fprintf(hf, "leaf-%d", i)
+		leaves[i] = NewDigest(h.Name(), hf.Sum(nil))
+	}
+	return leaves
+}
+
+func Test_MerkleRoot(t *testing.T) {
+	h := hasher.Default()
+
+	root, err := Digests(nil).Root()
+	assert.Nil(t, err)
+	assert.Nil(t, root)
+
+	leaves := testLeaves(h, 3)
+
+	// Single leaf is still domain separated
+	root, _ = leaves[:1].Root()
+	assert.Equal(t, merkleLeaf(h, leaves[0]), root.Hash())
+	assert.NotEqual(t, leaves[0], root)
+
+	// Odd node is promoted
+	l := merkleLeaves(h, leaves)
+	root, _ = leaves.Root()
+	assert.Equal(t, h.Name(), root.Algorithm())
+	assert.Equal(t, merkleNode(h, merkleNode(h, l[0], l[1]), l[2]), root.Hash())
+
+	// Duplicating the last leaf changes the root
+	dup, _ := append(leaves, leaves[2]).Root()
+	assert.NotEqual(t, root, dup)
+}
+
+func Test_MerkleProof(t *testing.T) {
+	h := hasher.Default()
+
+	for n := 1; n <= 9; n++ {
+		leaves := testLeaves(h, n)
+		root, _ := leaves.Root()
+
+		for i := range leaves {
+			proof, err := leaves.Proof(leaves[i])
+			assert.Nil(t, err)
+			assert.Equal(t, i, proof.Index)
+			assert.True(t, VerifyProof(root, leaves[i], proof))
+
+			if n > 1 {
+				// Wrong leaf
+				assert.False(t, VerifyProof(root, leaves[(i+1)%n], proof))
+				// Wrong position
+				moved := *proof
+				moved.Index = (i + 1) % n
+				assert.False(t, VerifyProof(root, leaves[i], &moved))
+			}
+			// Extra hash
+			extra := *proof
+			extra.Hashes = append(append([][]byte{}, proof.Hashes...), root.Hash())
+			assert.False(t, VerifyProof(root, leaves[i], &extra))
+		}
+	}
+
+	leaves := testLeaves(h, 4)
+	_, err := leaves.Proof(NewZeroDigest(h))
+	assert.Equal(t, ErrNotInTree, err)
+	_, err = NewMerkleProof(leaves, 4)
+	assert.Equal(t, ErrNotInTree, err)
+
+	proof, _ := leaves.Proof(leaves[1])
+	assert.False(t, VerifyProof(nil, leaves[1], proof))
+	assert.False(t, VerifyProof(NewZeroDigest(h), leaves[1], proof))
+	assert.False(t, VerifyProof(NewZeroDigest(h), leaves[1], nil))
+
+	// Block level proof
+	blk := NewBlock()
+	blk.Txs = leaves
+	blk.SetHash(h)
+
+	proof, err = blk.TxProof(leaves[3])
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(blk.Header.Root, leaves[3], proof))
+}
+
+func Test_MerkleInvalidLeaf(t *testing.T) {
+	h := hasher.Default()
+	leaves := testLeaves(h, 3)
+
+	// Missing algorithm
+	bad := append(Digests{}, leaves...)
+	bad[1] = Digest(leaves[1].Hash())
+	_, err := bad.Root()
+	assert.Equal(t, ErrInvalidLeaf, err)
+	_, err = NewMerkleProof(bad, 0)
+	assert.Equal(t, ErrInvalidLeaf, err)
+
+	_, err = Digests{Digest("nocolon")}.Root()
+	assert.Equal(t, ErrInvalidLeaf, err)
+	_, err = Digests{Digest(":empty")}.Root()
+	assert.Equal(t, ErrInvalidLeaf, err)
+
+	// Mixed algorithms
+	bad[1] = NewDigest("other", leaves[1].Hash())
+	_, err = bad.Root()
+	assert.Equal(t, ErrInvalidLeaf, err)
+}
@@ -0,0 +1,55 @@
+package bcpb
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidSignerSet is returned when the signer set parameters are not
+// consistent with its signers
+var ErrInvalidSignerSet = errors.New("invalid signer set")
+
+// Validate checks the parameters are consistent with the signers.  Signers
+// must be unique
+func (set *SignerSet) Validate() error {
+	if set.N <= 0 || int(set.N) != len(set.Signers) {
+		return ErrInvalidSignerSet
+	}
+	// Q is stored as the required signatures of the set output
+	if set.S <= 0 || set.S > set.N || set.Q <= 0 || set.Q > set.N || set.Q > math.MaxUint8 {
+		return ErrInvalidSignerSet
+	}
+
+	seen := make(map[string]struct{}, len(set.Signers))
+	for _, pk := range set.Signers {
+		if len(pk) == 0 {
+			return ErrInvalidSignerSet
+		}
+		if _, ok := seen[string(pk)]; ok {
+			return ErrInvalidSignerSet
+		}
+		seen[string(pk)] = struct{}{}
+	}
+
+	return nil
+}
+
+// Matches returns true if the header signers and parameters are those of the
+// set.  Signers must be in the same order
+func (set *SignerSet) Matches(header *BlockHeader) bool {
+	if header.N != set.N || header.S != set.S || header.Q != set.Q {
+		return false
+	}
+
+	if len(header.Signers) != len(set.Signers) {
+		return false
+	}
+
+	for i := range set.Signers {
+		if !set.Signers[i].Equal(header.Signers[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package bcpb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SignerSet(t *testing.T) {
+	set := &SignerSet{
+		Signers: []PublicKey{PublicKey("key1"), PublicKey("key2"), PublicKey("key3")},
+		N:       3,
+		S:       2,
+		Q:       2,
+	}
+	assert.Nil(t, set.Validate())
+
+	b, err := set.Marshal()
+	assert.Nil(t, err)
+
+	var set2 SignerSet
+	assert.Nil(t, set2.Unmarshal(b))
+	assert.Equal(t, set.Signers, set2.Signers)
+	assert.Equal(t, set.N, set2.N)
+	assert.Equal(t, set.S, set2.S)
+	assert.Equal(t, set.Q, set2.Q)
+
+	assert.NotNil(t, set2.Unmarshal(b[:len(b)-1]))
+
+	header := &BlockHeader{Signers: set.Signers, N: 3, S: 2, Q: 2}
+	assert.True(t, set.Matches(header))
+	header.Signers = []PublicKey{set.Signers[1], set.Signers[0], set.Signers[2]}
+	assert.False(t, set.Matches(header))
+	header.Signers = set.Signers
+	header.S = 1
+	assert.False(t, set.Matches(header))
+
+	// Duplicate signer
+	set.Signers[2] = set.Signers[0]
+	assert.Equal(t, ErrInvalidSignerSet, set.Validate())
+	// N does not match signers
+	set = &SignerSet{Signers: []PublicKey{PublicKey("key1")}, N: 2, S: 1, Q: 1}
+	assert.Equal(t, ErrInvalidSignerSet, set.Validate())
+	// Quorum larger than N
+	set = &SignerSet{Signers: []PublicKey{PublicKey("key1")}, N: 1, S: 1, Q: 2}
+	assert.Equal(t, ErrInvalidSignerSet, set.Validate())
+}
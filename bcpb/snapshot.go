@@ -0,0 +1,80 @@
+package bcpb
+
+import (
+	"encoding/binary"
+
+	"github.com/hexablock/hasher"
+)
+
+// snapshotDomain prefixes the snapshot contents when signing so a snapshot
+// signature can never be used as a block or commit signature
+const snapshotDomain = "snapshot:"
+
+// Digest returns the digest signers sign.  It covers the block digest, each
+// entry, each unspent output and the digest of each tx
+func (snap *Snapshot) Digest(h hasher.Hasher) Digest {
+	hf := h.New()
+	hf.Write([]byte(snapshotDomain))
+	hf.Write(snap.Block.Digest)
+
+	for _, e := range snap.Entries {
+		hf.Write(encodeSnapshotEntry(nil, e))
+	}
+	for _, o := range snap.Unspent {
+		hf.Write(encodeSnapshotOutput(nil, o))
+	}
+	for _, tx := range snap.Txs {
+		hf.Write(appendBytes(nil, tx.Digest))
+	}
+
+	return NewDigest(h.Name(), hf.Sum(nil))
+}
+
+// Sign sets the signature of the block signer with the public key
+func (snap *Snapshot) Sign(pubkey PublicKey, signature []byte) error {
+	header := snap.Block.Header
+
+	i := header.SignerIndex(pubkey)
+	if i < 0 {
+		return ErrSignerNotInBlock
+	}
+
+	if len(snap.Signatures) < len(header.Signers) {
+		sigs := make([][]byte, len(header.Signers))
+		copy(sigs, snap.Signatures)
+		snap.Signatures = sigs
+	}
+
+	if len(snap.Signatures[i]) != 0 {
+		return ErrSignerAlreadySigned
+	}
+
+	snap.Signatures[i] = signature
+	return nil
+}
+
+// encodeSnapshotEntry appends the length prefixed key and ref followed by the
+// output index to buf
+func encodeSnapshotEntry(buf []byte, e SnapshotEntry) []byte {
+	buf = appendBytes(buf, e.Key)
+	buf = appendBytes(buf, e.Ref)
+	return appendUint32(buf, uint32(e.Index))
+}
+
+// encodeSnapshotOutput appends the length prefixed ref followed by the output
+// index to buf
+func encodeSnapshotOutput(buf []byte, o SnapshotOutput) []byte {
+	buf = appendBytes(buf, o.Ref)
+	return appendUint32(buf, uint32(o.Index))
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return append(buf, b...)
+}
+
+// appendBytes appends b to buf prefixed with its uint32 length
+func appendBytes(buf, b []byte) []byte {
+	return append(appendUint32(buf, uint32(len(b))), b...)
+}
@@ -0,0 +1,57 @@
+package bcpb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/hasher"
+)
+
+func Test_Snapshot(t *testing.T) {
+	h := hasher.Default()
+
+	blk := NewBlock()
+	blk.SetSigners(PublicKey("key1"), PublicKey("key2"))
+	blk.SetHash(h)
+
+	tx := NewBaseTx()
+	tx.AddOutput(&TxOutput{DataKey: DataKey("snap:a"), Data: []byte("a")})
+	tx.SetDigest(h)
+
+	snap := &Snapshot{
+		Block:   blk,
+		Entries: []SnapshotEntry{{Key: DataKey("snap:a"), Ref: tx.Digest, Index: 0}},
+		Unspent: []SnapshotOutput{{Ref: tx.Digest, Index: 0}},
+		Txs:     []*Tx{tx},
+	}
+
+	d := snap.Digest(h)
+	assert.NotEqual(t, blk.Digest, d)
+	assert.NotEqual(t, CommitDigest(h, blk.Digest), d)
+
+	assert.Equal(t, ErrSignerNotInBlock, snap.Sign(PublicKey("key3"), []byte("sig")))
+	assert.Nil(t, snap.Sign(PublicKey("key1"), []byte("sig1")))
+	assert.Equal(t, ErrSignerAlreadySigned, snap.Sign(PublicKey("key1"), []byte("sig1")))
+
+	b, err := snap.Marshal()
+	assert.Nil(t, err)
+
+	var snap2 Snapshot
+	assert.Nil(t, snap2.Unmarshal(b))
+	assert.Equal(t, blk.Digest, snap2.Block.Digest)
+	assert.Equal(t, snap.Entries, snap2.Entries)
+	assert.Equal(t, snap.Unspent, snap2.Unspent)
+	assert.Equal(t, tx.Digest, snap2.Txs[0].Digest)
+	assert.Equal(t, [][]byte{[]byte("sig1"), {}}, snap2.Signatures)
+	assert.Equal(t, d, snap2.Digest(h))
+
+	// Any change to the state changes the digest
+	snap2.Entries[0].Index = 1
+	assert.NotEqual(t, d, snap2.Digest(h))
+	snap2.Entries[0].Index = 0
+	snap2.Unspent = nil
+	assert.NotEqual(t, d, snap2.Digest(h))
+
+	assert.NotNil(t, snap2.Unmarshal(b[:10]))
+}
@@ -3,8 +3,10 @@ package blockchain
 import (
 	"crypto/elliptic"
 	"errors"
+	"sync"
 
 	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/script"
 	"github.com/hexablock/blockchain/stores"
 	"github.com/hexablock/hasher"
 )
@@ -12,6 +14,8 @@ import (
 var (
 	errBaseTx                 = errors.New("base transaction")
 	errRequiresMoreSignatures = errors.New("requires more signatures")
+	errInvalidOutputIndex     = errors.New("invalid output index")
+	errRootMismatch           = errors.New("tx root mismatch")
 )
 
 // BlockValidator is the validator function called to validate a block before
@@ -22,9 +26,19 @@ type BlockValidator func(*bcpb.BlockHeader) error
 // output and the associated input as arguments. TxOutput <- TxInput
 type TxInputOutputValidator func(ref *bcpb.TxOutput, in *bcpb.TxInput) error
 
-// BlockStorage implements a store for ledger blocks
+// Batcher creates write batches spanning the BlockStorage, TxStorage,
+// DataKeyIndex, UTXOIndex and QueryIndex.  All stores must be backed by the same
+// database as the Batcher.
+type Batcher interface {
+	NewBatch() stores.Batch
+}
+
+// BlockStorage implements a store for ledger blocks.  All write operations take
+// a batch the write is to be part of.  A nil batch performs the write
+// immediately
 type BlockStorage interface {
-	// Get a block by its digest id
+	// Get a block by its digest id.  It must return stores.ErrBlockNotFound if
+	// the block does not exist
 	Get(bcpb.Digest) (*bcpb.Block, error)
 	// Returns the genesis  block
 	Genesis() (bcpb.Digest, *bcpb.Block)
@@ -34,39 +48,134 @@ type BlockStorage interface {
 	LastExec() (bcpb.Digest, *bcpb.Block)
 	// Sets the genesis block digest. It assumes the actual block is already in
 	// in the store
-	SetGenesis(bcpb.Digest) error
+	SetGenesis(stores.Batch, bcpb.Digest) error
 	// Set last block digest. It assumes the actual block is already in
 	// in the store
-	SetLast(bcpb.Digest) error
+	SetLast(stores.Batch, bcpb.Digest) error
 	// Sets the last executed block. It assumes the actual block is already in
 	// in the store
-	SetLastExec(bcpb.Digest) error
+	SetLastExec(stores.Batch, bcpb.Digest) error
 	// Returns true if the block by the given digest exists
 	Exists(bcpb.Digest) bool
-	// Adds a block to the ledger returning an error if it already exists
-	Add(*bcpb.Block) (bcpb.Digest, error)
+	// Adds a block to the ledger returning stores.ErrBlockExists if it
+	// already exists
+	Add(stores.Batch, *bcpb.Block) (bcpb.Digest, error)
+	// Removes a block along with its weight, branch head marker and commit
+	// certificate
+	Remove(stores.Batch, bcpb.Digest) error
 	// Iter iterates of each block in the ledger
 	Iter(f stores.BlockIterator) error
+	// Returns the cumulative signature weight of the branch ending at the
+	// block
+	Weight(bcpb.Digest) (uint64, error)
+	// Sets the cumulative signature weight of the branch ending at the block
+	SetWeight(stores.Batch, bcpb.Digest, uint64) error
+	// Returns the digests of all branch heads i.e. blocks without children
+	Tips() []bcpb.Digest
+	// Marks the block as a branch head
+	SetTip(stores.Batch, bcpb.Digest) error
+	// Unmarks the block as a branch head
+	RemoveTip(stores.Batch, bcpb.Digest) error
+	// Returns the commit certificate of the block.  It must return
+	// stores.ErrCertificateNotFound if the block has none
+	Certificate(bcpb.Digest) (*bcpb.CommitCertificate, error)
+	// Sets the commit certificate of the block
+	SetCertificate(stores.Batch, bcpb.Digest, *bcpb.CommitCertificate) error
+	// Returns the main chain block at the height with its Digest set.  It
+	// must return stores.ErrBlockNotFound if there is none
+	GetByHeight(uint32) (*bcpb.Block, error)
+	// Iterates over the main chain blocks by height starting at the given
+	// height in ascending or, if reverse, descending order.  A limit of zero
+	// or less iterates over all of them
+	IterHeight(start uint32, limit int, reverse bool, f stores.BlockIterator) error
+	// Sets the main chain block at the height
+	SetHeight(stores.Batch, uint32, bcpb.Digest) error
+	// Removes the main chain block at the height
+	RemoveHeight(stores.Batch, uint32) error
+	// Returns the signer set activated at the highest height not above the
+	// given one.  It must return stores.ErrSignerSetNotFound if there is none
+	SignerSet(uint32) (*bcpb.SignerSet, error)
+	// Sets the signer set activated at the height
+	SetSignerSet(stores.Batch, uint32, *bcpb.SignerSet) error
+	// Removes the signer set activated at the height
+	RemoveSignerSet(stores.Batch, uint32) error
 }
 
 // TxStorage implements a transaction store
 type TxStorage interface {
-	// Get a transaction
+	// Get a transaction.  It must return stores.ErrTxNotFound if the
+	// transaction does not exist and stores.ErrPruned if it has been pruned
 	Get(bcpb.Digest) (*bcpb.Tx, error)
 	// Set a transaction
-	Set(*bcpb.Tx) error
+	Set(stores.Batch, *bcpb.Tx) error
 	// Set a batch of transactions
-	SetBatch([]*bcpb.Tx) error
-	// Iterate over all transactions
+	SetBatch(stores.Batch, []*bcpb.Tx) error
+	// Remove a transaction
+	Remove(stores.Batch, bcpb.Digest) error
+	// Prune removes the body of a transaction while remembering it existed
+	Prune(stores.Batch, bcpb.Digest) error
+	// Iterate over all transactions skipping pruned ones
 	Iter(func(bcpb.Tx) error)
+	// Location of a transaction on the main chain.  It must return
+	// stores.ErrLocationNotFound if the transaction is not in a committed block.
+	// Locations are kept when a transaction is pruned
+	Location(bcpb.Digest) (stores.TxLocation, error)
+	SetLocation(stores.Batch, bcpb.Digest, stores.TxLocation) error
+	RemoveLocation(stores.Batch, bcpb.Digest) error
 }
 
 // DataKeyIndex is an index of DataKey to the txref and output index of all
-// unspent outputs.
+// unspent outputs.  It also holds the undo log of each committed block so the
+// index can be rewound.  Get must return stores.ErrDataKeyNotFound if the key
+// does not exist and stores.ErrDataKeyDeleted along with the deleting tx and
+// stores.TombstoneIndex if the key has been deleted.  Iter must skip deleted
+// keys
 type DataKeyIndex interface {
 	Get(key bcpb.DataKey) (bcpb.Digest, int32, error)
-	Set(key bcpb.DataKey, ref bcpb.Digest, idx int32) error
+	Set(b stores.Batch, key bcpb.DataKey, ref bcpb.Digest, idx int32) error
+	// Delete sets the tombstone of the key recording the tx deleting it
+	Delete(b stores.Batch, key bcpb.DataKey, ref bcpb.Digest) error
+	Remove(b stores.Batch, key bcpb.DataKey) error
 	Iter(prefix bcpb.DataKey, iter stores.DataKeyIterator) error
+	// Iter including deleted DataKeys.  These are given with the deleting tx
+	// and stores.TombstoneIndex
+	IterAll(prefix bcpb.DataKey, iter stores.DataKeyIterator) error
+	// Undo log of DataKey states prior to the block being committed
+	SetUndo(b stores.Batch, block bcpb.Digest, entries []stores.UndoEntry) error
+	Undo(block bcpb.Digest) ([]stores.UndoEntry, error)
+	RemoveUndo(b stores.Batch, block bcpb.Digest) error
+	// History of the states each DataKey was set to on the main chain
+	AddHistory(b stores.Batch, key bcpb.DataKey, e stores.HistoryEntry) error
+	History(key bcpb.DataKey, f stores.HistoryIterator) error
+	RemoveHistory(b stores.Batch, key bcpb.DataKey, height uint32) error
+}
+
+// UTXOIndex is the set of unspent outputs of the main chain keyed by tx digest
+// and output index.  Outputs are also indexed by the address of each of their
+// public keys.  It is updated as blocks are committed and reverted
+type UTXOIndex interface {
+	// Returns true if the output is unspent
+	Exists(ref bcpb.Digest, idx int32) bool
+	Add(b stores.Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error
+	Remove(b stores.Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error
+	// Iterates over the unspent outputs of the address in stores.OutPoint
+	// order starting after the given OutPoint
+	IterAddress(addr []byte, after []byte, f stores.UTXOIterator) error
+	// Iterates over all unspent outputs in stores.OutPoint order
+	Iter(f stores.UTXOIterator) error
+}
+
+// QueryIndex indexes the Tags, Labels and Metrics of the current output of each
+// DataKey on the main chain.  It is updated as DataKeys change state
+type QueryIndex interface {
+	Add(b stores.Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error
+	Remove(b stores.Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error
+	// Iterates over the DataKeys with the tag set to the value
+	IterTag(name, value string, f stores.DataKeyFunc) error
+	// Iterates over the DataKeys with the label
+	IterLabel(label string, f stores.DataKeyFunc) error
+	// Iterates over the DataKeys with the metric within min and max inclusive
+	IterMetric(name string, min, max float64, f stores.DataKeyFunc) error
 }
 
 // Blockchain is a blockchain instance that is able to perform all verification
@@ -77,24 +186,59 @@ type Blockchain struct {
 	// Block validation function
 	bv BlockValidator
 
+	// Creates batches for atomic writes across all stores
+	batcher Batcher
+
+	// Ledger event subscriptions
+	events *eventBus
+
+	// Serializes commit certificate updates
+	certMu sync.Mutex
+
+	// Output logic evaluators by tag
+	logicMu sync.RWMutex
+	logic   map[byte]LogicEvaluator
+
+	// Depth at which spent txs are pruned.  Zero disables pruning
+	pruneDepth uint32
+
 	blk *blockStore
 	tx  *txStore
 }
 
-// New instantiates a new blockchain.  By default block validation is disabled
+// New instantiates a new blockchain.  By default block validation is disabled.
+// It panics if a required field of the config is not set as the blockchain
+// cannot operate without it
 func New(conf *Config) *Blockchain {
-	return &Blockchain{
+	if err := conf.validate(); err != nil {
+		panic(err)
+	}
+
+	bc := &Blockchain{
 		// Hash function
 		h: conf.Hasher,
 		// Elliptic curve
 		curve: conf.Curve,
 		// Disable block validation
 		bv: func(*bcpb.BlockHeader) error { return nil },
+		// Write batches
+		batcher: conf.Batcher,
+		// Event subscriptions
+		events: newEventBus(),
 		// Block store
 		blk: &blockStore{conf.BlockStorage},
 		// Tx store
-		tx: &txStore{conf.TxStorage, conf.DataKeyIndex},
+		tx: &txStore{conf.TxStorage, conf.DataKeyIndex, conf.UTXOIndex, conf.QueryIndex},
+		// Logic evaluators
+		logic: make(map[byte]LogicEvaluator),
+		// Pruning
+		pruneDepth: conf.PruneDepth,
 	}
+
+	// Built-in script logic
+	bc.logic[script.Tag] = bc.evaluateScript
+
+	return bc
 }
 
 // SetBlockValidator sets the block validator function
@@ -125,41 +269,248 @@ func (bc *Blockchain) Last() *bcpb.Block {
 	return last
 }
 
+// GetByHeight returns the main chain block at the height.  It returns
+// stores.ErrBlockNotFound if the main chain has no block at the height.  The
+// main chain of a ledger restored from a snapshot starts at the height of the
+// snapshot block i.e. that of Genesis
+func (bc *Blockchain) GetByHeight(height uint32) (*bcpb.Block, error) {
+	return bc.blk.st.GetByHeight(height)
+}
+
+// BlocksByHeight returns the main chain blocks from the start height up or,
+// if reverse, down to genesis.  At most limit blocks are returned unless limit
+// is zero or less.  Iteration stops at the first height without a block so on a
+// ledger restored from a snapshot it must start at the height of Genesis
+func (bc *Blockchain) BlocksByHeight(start uint32, limit int, reverse bool) ([]*bcpb.Block, error) {
+	blocks := make([]*bcpb.Block, 0)
+	err := bc.blk.st.IterHeight(start, limit, reverse, func(id bcpb.Digest, blk *bcpb.Block) error {
+		blocks = append(blocks, blk)
+		return nil
+	})
+	return blocks, err
+}
+
+// ReindexHeights rebuilds the height index and the tx locations of the main
+// chain by walking back from the last block to genesis.  Both are only written
+// as blocks are committed so ledgers with blocks committed before the indexes
+// existed must call this once before looking up blocks by height or the status
+// of their txs, otherwise those txs are reported as pending.  The indexes are
+// written in chunks so if the rebuild fails it must be run again
+func (bc *Blockchain) ReindexHeights() error {
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return stores.ErrBlockNotFound
+	}
+
+	path, err := bc.blk.chain(lid)
+	if err != nil {
+		return err
+	}
+
+	cb := bc.newChunkedBatch()
+	defer cb.discard()
+
+	for _, blk := range path {
+		if err = bc.blk.st.SetHeight(cb.batch, blk.Height(), blk.Digest); err != nil {
+			return err
+		}
+		for i, tid := range blk.Txs {
+			loc := stores.TxLocation{Block: blk.Digest, Height: blk.Height(), Index: int32(i)}
+			if err = bc.tx.tx.SetLocation(cb.batch, tid, loc); err != nil {
+				return err
+			}
+		}
+		if err = cb.step(); err != nil {
+			return err
+		}
+	}
+
+	return cb.commit()
+}
+
+// ReindexUTXOs rebuilds the UTXOIndex by replaying the txs of the main chain
+// from genesis up to the last block.  Unspent outputs are only indexed as
+// blocks are committed so ledgers with blocks committed before the index
+// existed must call this once before appending blocks or validating txs,
+// otherwise spends of their outputs are rejected as already spent.  The bodies
+// of all main chain txs are required.  The index is written in chunks so if the
+// rebuild fails it must be run again.  Ledgers restored from a snapshot have
+// their index written by Restore and are left as is
+func (bc *Blockchain) ReindexUTXOs() error {
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return stores.ErrBlockNotFound
+	}
+
+	path, err := bc.blk.chain(lid)
+	if err != nil {
+		return err
+	}
+	if path[len(path)-1].Header.Height > 0 {
+		return nil
+	}
+
+	// Outputs currently in the index are removed before replaying
+	type outpoint struct {
+		ref bcpb.Digest
+		idx int32
+	}
+	indexed := make([]outpoint, 0)
+	err = bc.tx.utxo.Iter(func(ref bcpb.Digest, idx int32) bool {
+		indexed = append(indexed, outpoint{ref.Copy(), idx})
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	cb := bc.newChunkedBatch()
+	defer cb.discard()
+
+	for _, o := range indexed {
+		tx, err := bc.tx.Get(o.ref)
+		if err != nil {
+			return err
+		}
+		if int(o.idx) < len(tx.Outputs) {
+			if err = bc.tx.utxo.Remove(cb.batch, o.ref, o.idx, tx.Outputs[o.idx]); err != nil {
+				return err
+			}
+		}
+		if err = cb.step(); err != nil {
+			return err
+		}
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		txs, err := bc.getBlockTxs(path[i])
+		if err != nil {
+			return err
+		}
+
+		if err = bc.reindexUTXOs(cb.batch, txs); err != nil {
+			return err
+		}
+		if err = cb.step(); err != nil {
+			return err
+		}
+	}
+
+	return cb.commit()
+}
+
+// reindexUTXOs removes the outputs spent by the txs from the UTXOIndex and
+// adds their outputs
+func (bc *Blockchain) reindexUTXOs(batch stores.Batch, txs []*bcpb.Tx) error {
+	for _, tx := range txs {
+		for _, in := range tx.Inputs {
+			if in.IsBase() {
+				continue
+			}
+
+			ref, err := bc.tx.Get(in.Ref)
+			if err != nil {
+				return err
+			}
+			if in.Index < 0 || int(in.Index) >= len(ref.Outputs) {
+				return errInvalidOutputIndex
+			}
+			if err = bc.tx.utxo.Remove(batch, in.Ref, in.Index, ref.Outputs[in.Index]); err != nil {
+				return err
+			}
+		}
+
+		for i, txo := range tx.Outputs {
+			if err := bc.tx.utxo.Add(batch, tx.Digest, int32(i), txo); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Tips returns the digests of the heads of all known branches
+func (bc *Blockchain) Tips() []bcpb.Digest {
+	return bc.blk.st.Tips()
+}
+
 // SetGenesis sets the genesis block and the associated transactions
 func (bc *Blockchain) SetGenesis(genesis *bcpb.Block, txs []*bcpb.Tx) error {
-	err := bc.validateBlock(genesis, txs)
+	view := newLedgerView(bc.tx)
+	weight, err := bc.validateBlock(genesis, txs, view)
 	if err != nil {
 		return err
 	}
 
-	err = bc.blk.SetGenesis(genesis)
-	if err == nil {
-		// If we succeed we set the last block digest to the zero hash
-		err = bc.blk.st.SetLast(bcpb.NewZeroDigest(bc.h))
+	batch := bc.batcher.NewBatch()
+	defer batch.Discard()
+
+	if err = bc.tx.SetBatch(batch, view, txs); err != nil {
+		return err
+	}
+
+	if err = bc.blk.SetGenesis(batch, genesis, weight); err != nil {
+		return err
 	}
 
-	return err
+	// If we succeed we set the last block digest to the zero hash
+	if err = bc.blk.st.SetLast(batch, bcpb.NewZeroDigest(bc.h)); err != nil {
+		return err
+	}
+
+	return batch.Commit()
 }
 
 // SetLastExec marks the given digest as the last executed block
 func (bc *Blockchain) SetLastExec(digest bcpb.Digest) error {
-	return bc.blk.st.SetLastExec(digest)
+	return bc.blk.st.SetLastExec(nil, digest)
 }
 
 // Append appends the block and txs to the ledger.  The supplied transactions
-// must be part of the block.  This does not update the last block reference or
-// index any of the txos
+// must be part of the block.  The block may extend any known block, in which
+// case a new branch is started.  The txs are validated against the state of
+// the branch the block extends including the blocks of it that are not
+// committed i.e. their inputs must be unspent and DataKeys created by them must
+// not exist on it.  This does not update the last block reference or index any
+// of the txos.  The txs and block are written atomically
 func (bc *Blockchain) Append(blk *bcpb.Block, txs []*bcpb.Tx) (bcpb.Digest, error) {
-	err := bc.validateBlock(blk, txs)
-	if err == nil {
-		return bc.blk.Append(blk)
+	view, err := bc.branchView(blk.Header.PrevBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	weight, err := bc.validateBlock(blk, txs, view)
+	if err != nil {
+		return nil, err
 	}
-	return nil, err
+
+	batch := bc.batcher.NewBatch()
+	defer batch.Discard()
+
+	if err = bc.tx.SetBatch(batch, view, txs); err != nil {
+		return nil, err
+	}
+
+	id, err := bc.blk.Append(batch, blk, weight)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = batch.Commit(); err != nil {
+		return nil, err
+	}
+
+	bc.events.publish(Event{Type: EventBlockAppended, Block: blk})
+	return id, nil
 }
 
 // Commit commits the block given by the id. It ensures it is the next in line
 // i.e. the previous hash matches the current last block, sets the last block
-// to the given id and indexes all transaction outputs in the block
+// to the given id and indexes all transaction outputs in the block.  Blocks
+// with a Q greater than 0 must have at least Q valid commit signatures, see
+// AddCommitSignature.  Either all of the changes are persisted or none of them
+// are.  In pruned mode spent txs are pruned once the block is committed
 func (bc *Blockchain) Commit(id bcpb.Digest) error {
 	// Get stored block thats being committed
 	blk, err := bc.blk.st.Get(id)
@@ -173,14 +524,21 @@ func (bc *Blockchain) Commit(id bcpb.Digest) error {
 		return ErrPrevBlockMismatch
 	}
 
-	// Set the given id as the last block
-	err = bc.blk.st.SetLast(id)
-	if err == nil {
-		// Index the tx outputs
-		err = bc.indexTxos(blk)
+	w := bc.newLedgerWriter()
+	defer w.batch.Discard()
+
+	// Index the tx outputs and set the given id as the last block
+	blk.Digest = id
+	if err = w.apply(id, blk); err != nil {
+		return err
+	}
+
+	if err = w.commit(); err != nil {
+		return err
 	}
 
-	return err
+	bc.sweep(blk.Height(), blk.Height())
+	return nil
 }
 
 // GetTXO returns the txo referenced by the TxInput. It returns an error
@@ -189,11 +547,11 @@ func (bc *Blockchain) GetTXO(txi *bcpb.TxInput) (*bcpb.TxOutput, error) {
 	if txi.IsBase() {
 		return nil, errBaseTx
 	}
-	return bc.validateRegTxInput(txi)
+	return bc.validateRegTxInput(txi, bc.chainContext())
 }
 
 // GetTXOByDataKey returns the TxOutput for the given key.  It is the DataKey's
-// last state
+// last state.  stores.ErrDataKeyDeleted is returned if the key has been deleted
 func (bc *Blockchain) GetTXOByDataKey(key bcpb.DataKey) (*bcpb.TxOutput, error) {
 	tx, i, err := bc.tx.GetDataKeyTx(key)
 	if err != nil {
@@ -203,16 +561,15 @@ func (bc *Blockchain) GetTXOByDataKey(key bcpb.DataKey) (*bcpb.TxOutput, error)
 	return tx.Outputs[i], nil
 }
 
-func (bc *Blockchain) indexTxos(blk *bcpb.Block) (err error) {
-	// Get txs in the block from the tx store
-	txs := make([]*bcpb.Tx, len(blk.Txs))
+// getBlockTxs returns the txs in the block from the tx store
+func (bc *Blockchain) getBlockTxs(blk *bcpb.Block) (txs []*bcpb.Tx, err error) {
+	txs = make([]*bcpb.Tx, len(blk.Txs))
 	for i, tid := range blk.Txs {
 		txs[i], err = bc.tx.Get(tid)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Index the tx outputs
-	return bc.tx.indexTxsOutputs(txs)
+	return txs, nil
 }
@@ -16,6 +16,9 @@ func testBlockchainConf() *Config {
 	conf.BlockStorage = stores.NewBadgerBlockStorage(testDB, []byte("test-prefix/"), conf.Hasher)
 	conf.TxStorage = stores.NewBadgerTxStorage(testDB, []byte("test-prefix/"))
 	conf.DataKeyIndex = stores.NewBadgerDataKeyIndex(testDB, []byte("test-prefix/"))
+	conf.UTXOIndex = stores.NewBadgerUTXOIndex(testDB, []byte("test-prefix/"), conf.Hasher)
+	conf.QueryIndex = stores.NewBadgerQueryIndex(testDB, []byte("test-prefix/"))
+	conf.Batcher = stores.NewBadgerBatcher(testDB)
 
 	return conf
 }
@@ -174,3 +177,16 @@ func Test_Blockchain(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+func Test_New_RequiresBatcher(t *testing.T) {
+	conf := testBlockchainConf()
+	conf.Batcher = nil
+
+	defer func() {
+		err, ok := recover().(error)
+		if assert.True(t, ok) {
+			assert.Equal(t, "config: Batcher required", err.Error())
+		}
+	}()
+	New(conf)
+}
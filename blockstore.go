@@ -6,12 +6,14 @@ import (
 	"time"
 
 	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
 	"github.com/hexablock/hasher"
 )
 
 var (
-	errInvalidNonce   = errors.New("invalid nonce")
-	errHeightMismatch = errors.New("height mismatch")
+	errInvalidNonce     = errors.New("invalid nonce")
+	errHeightMismatch   = errors.New("height mismatch")
+	errNoCommonAncestor = errors.New("no common ancestor")
 	// ErrPrevBlockMismatch is returned when a block references a previous
 	// block that is not the actual previous block
 	ErrPrevBlockMismatch = errors.New("previous block mismatch")
@@ -33,9 +35,10 @@ type blockStore struct {
 	st BlockStorage
 }
 
-// SetGenesis sets the genesis block for the blockchain.  This can only be called
-// once
-func (bc *blockStore) SetGenesis(genesis *bcpb.Block) error {
+// SetGenesis sets the genesis block for the blockchain as part of the batch.
+// The genesis block is the first branch head with the given weight. This can
+// only be called once
+func (bc *blockStore) SetGenesis(batch stores.Batch, genesis *bcpb.Block, weight int32) error {
 	store := bc.st
 
 	// Check if we already have a genesis block
@@ -44,41 +47,148 @@ func (bc *blockStore) SetGenesis(genesis *bcpb.Block) error {
 	}
 
 	// This will return an error if it already exists
-	gid, err := store.Add(genesis)
+	gid, err := store.Add(batch, genesis)
 	if err != nil {
 		return err
 	}
 
-	return store.SetGenesis(gid)
+	if err = store.SetWeight(batch, gid, uint64(weight)); err != nil {
+		return err
+	}
+	if err = store.SetTip(batch, gid); err != nil {
+		return err
+	}
+
+	return store.SetGenesis(batch, gid)
 }
 
 // Append verifies and validates the block before appending it to the ledger
-func (bc *blockStore) Append(blk *bcpb.Block) (bcpb.Digest, error) {
+// as part of the batch.  The block may extend any stored block.  The branch
+// weight of the block is that of its parent plus the given signature weight of
+// the block.  The block replaces its parent as a branch head.
+func (bc *blockStore) Append(batch stores.Batch, blk *bcpb.Block, weight int32) (bcpb.Digest, error) {
 	if err := bc.checkPrevHeightNonce(blk.Header); err != nil {
 		return nil, err
 	}
 
-	return bc.st.Add(blk)
+	id, err := bc.st.Add(batch, blk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks stored prior to weight tracking have no weight
+	pw, _ := bc.st.Weight(blk.Header.PrevBlock)
+	if err = bc.st.SetWeight(batch, id, pw+uint64(weight)); err != nil {
+		return nil, err
+	}
+
+	if err = bc.st.RemoveTip(batch, blk.Header.PrevBlock); err != nil {
+		return nil, err
+	}
+
+	return id, bc.st.SetTip(batch, id)
 }
 
-// checkPrevHeightNonce checks the height, nonce and previous block hash in that
-// order.  Height and nonce are checked first as they are cheaper operations.
+// checkPrevHeightNonce checks the previous block exists followed by the height
+// and nonce against it.
 func (bc *blockStore) checkPrevHeightNonce(blk *bcpb.BlockHeader) (err error) {
-	lid, last := bc.st.Last()
+	prev, err := bc.st.Get(blk.PrevBlock)
+	if err != nil {
+		// Check prev block exists
+		return ErrPrevBlockMismatch
+	}
 
-	if blk.Height != last.Header.Height+1 {
+	if blk.Height != prev.Header.Height+1 {
 		// Check height match
 		return errHeightMismatch
 
-	} else if blk.Nonce < last.Header.Nonce {
+	} else if blk.Nonce < prev.Header.Nonce {
 		// New nonce is greater than old one
 		return errInvalidNonce
 
-	} else if !lid.Equal(blk.PrevBlock) {
-		// Check prev block match
-		return ErrPrevBlockMismatch
-
 	}
 
 	return nil
 }
+
+// get returns the block for the digest with the Digest field set.  A nil block
+// is returned if it does not exist
+func (bc *blockStore) get(id bcpb.Digest) *bcpb.Block {
+	blk, err := bc.st.Get(id)
+	if err != nil {
+		return nil
+	}
+	blk.Digest = id
+	return blk
+}
+
+// branches walks back from both blocks to their common ancestor.  It returns
+// the blocks of each branch above the ancestor in descending height order.  A
+// digest that is not in the store e.g. the zero digest is treated as the empty
+// chain
+func (bc *blockStore) branches(a, b bcpb.Digest) (aBranch, bBranch []*bcpb.Block, err error) {
+	ablk, bblk := bc.get(a), bc.get(b)
+
+	for !a.Equal(b) {
+		if ablk != nil && (bblk == nil || ablk.Header.Height >= bblk.Header.Height) {
+			aBranch = append(aBranch, ablk)
+			a = ablk.Header.PrevBlock
+			ablk = bc.get(a)
+
+		} else if bblk != nil {
+			bBranch = append(bBranch, bblk)
+			b = bblk.Header.PrevBlock
+			bblk = bc.get(b)
+
+		} else {
+			return nil, nil, errNoCommonAncestor
+		}
+	}
+
+	return aBranch, bBranch, nil
+}
+
+// walkBack returns the blocks from the given block back to, but excluding,
+// the given height in descending height order
+func (bc *blockStore) walkBack(id bcpb.Digest, height uint32) ([]*bcpb.Block, error) {
+	path := make([]*bcpb.Block, 0)
+
+	for {
+		blk := bc.get(id)
+		if blk == nil {
+			return nil, stores.ErrBlockNotFound
+		}
+		if blk.Header.Height <= height {
+			break
+		}
+
+		path = append(path, blk)
+		id = blk.Header.PrevBlock
+	}
+
+	return path, nil
+}
+
+// chain returns the blocks from the given block back to and including the
+// genesis block in descending height order.  The genesis block is the one
+// stored as such which is not at height 0 on a ledger restored from a snapshot
+func (bc *blockStore) chain(id bcpb.Digest) ([]*bcpb.Block, error) {
+	gid, _ := bc.st.Genesis()
+	path := make([]*bcpb.Block, 0)
+
+	for {
+		blk := bc.get(id)
+		if blk == nil {
+			return nil, stores.ErrBlockNotFound
+		}
+
+		path = append(path, blk)
+		if id.Equal(gid) {
+			return path, nil
+		}
+		if blk.Header.Height == 0 {
+			return nil, stores.ErrBlockNotFound
+		}
+		id = blk.Header.PrevBlock
+	}
+}
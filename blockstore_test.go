@@ -66,24 +66,24 @@ func Test_BlockStore(t *testing.T) {
 	genesis.SetTxs([]*bcpb.Tx{btx}, h)
 	genesis.SetHash(h)
 
-	err := bs.SetGenesis(genesis)
+	err := bs.SetGenesis(nil, genesis, 1)
 	assert.Nil(t, err)
-	bs.st.SetLast(genesis.Digest)
-	bs.st.SetLastExec(genesis.Digest)
+	bs.st.SetLast(nil, genesis.Digest)
+	bs.st.SetLastExec(nil, genesis.Digest)
 
 	lid1, last := bdb.Last()
 	assert.Equal(t, uint32(0), last.Height())
 
-	err = bs.SetGenesis(genesis)
+	err = bs.SetGenesis(nil, genesis, 1)
 	assert.NotNil(t, err)
 
 	// First block
 	blk := nextBlock(bs)
 	blk.SetTxs([]*bcpb.Tx{bcpb.NewBaseTx()}, h)
 
-	id, err := bs.Append(blk)
+	id, err := bs.Append(nil, blk, 1)
 	assert.Nil(t, err)
-	err = bs.st.SetLast(id)
+	err = bs.st.SetLast(nil, id)
 	assert.Nil(t, err)
 
 	lid2, last := bdb.Last()
@@ -95,16 +95,43 @@ func Test_BlockStore(t *testing.T) {
 	b2.Header.Height = last.Height() + 1
 	b2.Header.PrevBlock = lid2
 	b2.Header.Nonce = 1
-	_, err = bs.Append(&b2)
+	_, err = bs.Append(nil, &b2, 1)
 	assert.Equal(t, errInvalidNonce, err)
 
 	b2.Header.Height = 0
-	_, err = bs.Append(&b2)
+	_, err = bs.Append(nil, &b2, 1)
 	assert.Equal(t, errHeightMismatch, err)
 
 	b3 := nextBlock(bs)
 	b3.Header.PrevBlock = lid1
-	_, err = bs.Append(b3)
+	_, err = bs.Append(nil, b3, 1)
+	assert.Equal(t, errHeightMismatch, err)
+
+	b3.Header.PrevBlock = bcpb.NewZeroDigest(h)
+	_, err = bs.Append(nil, b3, 1)
 	assert.Equal(t, ErrPrevBlockMismatch, err)
 
+	// Fork off of genesis
+	b4 := nextBlock(bs)
+	b4.Header.PrevBlock = lid1
+	b4.Header.Height = 1
+	fid, err := bs.Append(nil, b4, 2)
+	assert.Nil(t, err)
+
+	tips := bdb.Tips()
+	assert.Equal(t, 2, len(tips))
+
+	w, err := bdb.Weight(lid2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), w)
+	w, err = bdb.Weight(fid)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), w)
+
+	main, fork, err := bs.branches(lid2, fid)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(main))
+	assert.Equal(t, 1, len(fork))
+	assert.Equal(t, lid2, main[0].Digest)
+	assert.Equal(t, fid, fork[0].Digest)
 }
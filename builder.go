@@ -0,0 +1,116 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+// ErrNoCommittedBlock is returned when building a block before any block,
+// including genesis, has been committed
+var ErrNoCommittedBlock = errors.New("no committed block")
+
+// TxSource supplies pending transactions to be included in a new block e.g. a
+// mempool.  Reap returns up to max txs in the order they should be included.
+// A max less than 1 returns all txs
+type TxSource interface {
+	Reap(max int) []*bcpb.Tx
+}
+
+// NewNextBlock returns a new block extending the last committed block.  The
+// height, previous block and nonce are derived from the last block.  The
+// signers are set in the given order with the proposer added if not already
+// a signer.  The txs are set and the block hashed, leaving it ready to be
+// signed.
+func (bc *Blockchain) NewNextBlock(proposer bcpb.PublicKey, signers []bcpb.PublicKey,
+	txs []*bcpb.Tx, n, s, q int32) (*bcpb.Block, error) {
+
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return nil, ErrNoCommittedBlock
+	}
+
+	blk := bcpb.NewBlock()
+	blk.Header.Height = last.Header.Height + 1
+	blk.Header.PrevBlock = lid
+	blk.Header.Nonce = last.Header.Nonce + 1
+
+	// Signers must be set first as it resets the signatures
+	blk.SetSigners(signers...)
+	blk.SetProposer(proposer)
+
+	blk.Header.N = n
+	blk.Header.S = s
+	blk.Header.Q = q
+
+	blk.SetTxs(txs, bc.h)
+	blk.SetHash(bc.h)
+
+	return blk, nil
+}
+
+// CollectTxs returns up to max txs from the source that are valid against the
+// current ledger state.  Txs that fail validation or conflict with a tx
+// already collected are skipped.  A max less than 1 collects all valid txs
+func (bc *Blockchain) CollectTxs(src TxSource, max int) []*bcpb.Tx {
+	pending := src.Reap(0)
+
+	txs := make([]*bcpb.Tx, 0, len(pending))
+	claimed := make(map[string]struct{})
+
+	for _, tx := range pending {
+		if max > 0 && len(txs) == max {
+			break
+		}
+
+		if err := bc.ValidateTx(tx); err != nil {
+			continue
+		}
+
+		claims := TxClaims(tx)
+		if hasClaim(claimed, claims) {
+			continue
+		}
+
+		for _, c := range claims {
+			claimed[c] = struct{}{}
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+// TxClaims returns the outputs spent and DataKeys written by the tx.  No two
+// txs in a block may share a claim
+func TxClaims(tx *bcpb.Tx) []string {
+	claims := make([]string, 0, len(tx.Inputs)+len(tx.Outputs))
+
+	for _, in := range tx.Inputs {
+		if in.IsBase() {
+			continue
+		}
+
+		idx := make([]byte, 4)
+		binary.BigEndian.PutUint32(idx, uint32(in.Index))
+		claims = append(claims, "o/"+string(in.Ref)+string(idx))
+	}
+
+	for _, out := range tx.Outputs {
+		if len(out.DataKey) > 0 {
+			claims = append(claims, "k/"+string(out.DataKey))
+		}
+	}
+
+	return claims
+}
+
+func hasClaim(claimed map[string]struct{}, claims []string) bool {
+	for _, c := range claims {
+		if _, ok := claimed[c]; ok {
+			return true
+		}
+	}
+	return false
+}
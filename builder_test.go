@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+type testTxSource []*bcpb.Tx
+
+func (src testTxSource) Reap(max int) []*bcpb.Tx {
+	if max > 0 && max < len(src) {
+		return src[:max]
+	}
+	return src
+}
+
+func Test_Blockchain_NewNextBlock(t *testing.T) {
+	conf := testBlockchainConfPrefix("builder/")
+	bc := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	_, err := bc.NewNextBlock(kp1.PublicKey, nil, nil, 1, 1, 1)
+	assert.Equal(t, ErrNoCommittedBlock, err)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "build:a", "build:b")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp1)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// Spends an unknown tx
+	invalid := bcpb.NewTx()
+	invalid.AddInput(bcpb.NewTxInput(bcpb.NewZeroDigest(bc.Hasher()), 0, nil))
+	invalid.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("build:x")})
+	invalid.SetDigest(bc.Hasher())
+
+	// Valid, conflicting and invalid txs
+	src := testTxSource{
+		testUpdateTx(t, bc, "build:a", "1"),
+		testUpdateTx(t, bc, "build:a", "2"),
+		invalid,
+		testBaseTx(bc, "build:c"),
+		testUpdateTx(t, bc, "build:b", "1"),
+	}
+
+	txs := bc.CollectTxs(src, 0)
+	assert.Equal(t, 3, len(txs))
+	assert.Equal(t, src[0].Digest, txs[0].Digest)
+	assert.Equal(t, src[3].Digest, txs[1].Digest)
+	assert.Equal(t, src[4].Digest, txs[2].Digest)
+
+	txs = bc.CollectTxs(src, 2)
+	assert.Equal(t, 2, len(txs))
+
+	blk, err := bc.NewNextBlock(kp2.PublicKey, []bcpb.PublicKey{kp1.PublicKey}, txs, 2, 2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), blk.Header.Height)
+	assert.Equal(t, genesis.Digest, blk.Header.PrevBlock)
+	assert.Equal(t, genesis.Header.Nonce+1, blk.Header.Nonce)
+	assert.Equal(t, 2, len(blk.Header.Signers))
+	assert.Equal(t, 2, len(blk.Signatures))
+	assert.Equal(t, kp2.PublicKey, blk.Header.Proposer())
+	assert.Equal(t, blk.Header.Hash(bc.Hasher()), blk.Digest)
+
+	for _, kp := range []*keypair.KeyPair{kp1, kp2} {
+		sig, _ := kp.Sign(blk.Digest)
+		assert.Nil(t, blk.Sign(kp.PublicKey, sig))
+	}
+
+	id, err := bc.Append(blk, txs)
+	assert.Nil(t, err)
+	testCommit(t, bc, id, kp1, kp2)
+	assert.Equal(t, id, bc.Last().Digest)
+
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("build:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+}
@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// ErrCommitQuorum is returned when committing a block that does not have Q
+// valid commit signatures
+var ErrCommitQuorum = errors.New("commit quorum not reached")
+
+// CommitCertificate returns the commit certificate of the block
+func (bc *Blockchain) CommitCertificate(id bcpb.Digest) (*bcpb.CommitCertificate, error) {
+	return bc.blk.st.Certificate(id)
+}
+
+// AddCommitSignature verifies the commit signature of a block signer and adds
+// it to the commit certificate of the block.  The signature must be over the
+// bcpb.CommitDigest of the block.  It returns the number of valid commit
+// signatures the block now has
+func (bc *Blockchain) AddCommitSignature(id bcpb.Digest, pubkey bcpb.PublicKey, signature []byte) (int32, error) {
+	bc.certMu.Lock()
+	defer bc.certMu.Unlock()
+
+	blk := bc.blk.get(id)
+	if blk == nil {
+		return 0, stores.ErrBlockNotFound
+	}
+
+	cert, err := bc.blk.st.Certificate(id)
+	if err == stores.ErrCertificateNotFound {
+		cert = bcpb.NewCommitCertificate(blk)
+	} else if err != nil {
+		return 0, err
+	}
+
+	kp := keypair.New(bc.curve, bc.h)
+	kp.PublicKey = pubkey
+	if !kp.VerifySignature(bcpb.CommitDigest(bc.h, id), signature) {
+		return 0, bcpb.ErrSignatureVerificationFailed
+	}
+
+	if err = cert.Sign(blk.Header, pubkey, signature); err != nil {
+		return 0, err
+	}
+
+	if err = bc.blk.st.SetCertificate(nil, id, cert); err != nil {
+		return 0, err
+	}
+
+	return bc.commitSignatureCount(blk.Header, cert), nil
+}
+
+// checkCommitQuorum returns ErrCommitQuorum if the block's commit certificate
+// has fewer than Q valid signatures.  Blocks with a Q less than 1 do not
+// require a certificate
+func (bc *Blockchain) checkCommitQuorum(id bcpb.Digest, blk *bcpb.Block) error {
+	if blk.Header.Q <= 0 {
+		return nil
+	}
+
+	cert, err := bc.blk.st.Certificate(id)
+	if err == stores.ErrCertificateNotFound {
+		return ErrCommitQuorum
+	} else if err != nil {
+		return err
+	}
+
+	if bc.commitSignatureCount(blk.Header, cert) < blk.Header.Q {
+		return ErrCommitQuorum
+	}
+
+	return nil
+}
+
+func (bc *Blockchain) commitSignatureCount(header *bcpb.BlockHeader, cert *bcpb.CommitCertificate) int32 {
+	digest := bcpb.CommitDigest(bc.h, cert.Block)
+	return keypair.CountSignatures(bc.curve, bc.h, digest, header.Signers, cert.Signatures)
+}
@@ -0,0 +1,91 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func Test_Blockchain_CommitCertificate(t *testing.T) {
+	conf := testBlockchainConfPrefix("cert/")
+	bc := New(conf)
+
+	kps := make([]*keypair.KeyPair, 4)
+	for i := range kps {
+		kps[i], _ = keypair.Generate(conf.Curve, conf.Hasher)
+	}
+
+	// Q of 0 does not require a certificate
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "cert:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kps[0])
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	signers := []bcpb.PublicKey{kps[0].PublicKey, kps[1].PublicKey, kps[2].PublicKey}
+	blk, err := bc.NewNextBlock(kps[0].PublicKey, signers, nil, 3, 1, 2)
+	assert.Nil(t, err)
+	bsig, _ := kps[0].Sign(blk.Digest)
+	blk.Sign(kps[0].PublicKey, bsig)
+
+	id, err := bc.Append(blk, nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, ErrCommitQuorum, bc.Commit(id))
+	_, err = bc.CommitCertificate(id)
+	assert.Equal(t, stores.ErrCertificateNotFound, err)
+
+	_, err = bc.AddCommitSignature(bcpb.NewZeroDigest(bc.Hasher()), kps[0].PublicKey, bsig)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+
+	// Block signatures are not commit signatures
+	_, err = bc.AddCommitSignature(id, kps[0].PublicKey, bsig)
+	assert.Equal(t, bcpb.ErrSignatureVerificationFailed, err)
+
+	cd := bcpb.CommitDigest(bc.Hasher(), id)
+	sigs := make([][]byte, len(kps))
+	for i, kp := range kps {
+		sigs[i], _ = kp.Sign(cd)
+	}
+
+	_, err = bc.AddCommitSignature(id, kps[3].PublicKey, sigs[3])
+	assert.Equal(t, bcpb.ErrSignerNotInBlock, err)
+
+	c, err := bc.AddCommitSignature(id, kps[1].PublicKey, sigs[1])
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), c)
+	_, err = bc.AddCommitSignature(id, kps[1].PublicKey, sigs[1])
+	assert.Equal(t, bcpb.ErrSignerAlreadySigned, err)
+	assert.Equal(t, ErrCommitQuorum, bc.Commit(id))
+
+	c, err = bc.AddCommitSignature(id, kps[2].PublicKey, sigs[2])
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), c)
+
+	cert, err := bc.CommitCertificate(id)
+	assert.Nil(t, err)
+	assert.Equal(t, id, cert.Block)
+	assert.Equal(t, 3, len(cert.Signatures))
+	assert.Equal(t, 0, len(cert.Signatures[0]))
+
+	assert.Nil(t, bc.Commit(id))
+	assert.Equal(t, id, bc.Last().Digest)
+
+	// Repeating a signer does not count its signature twice
+	signers = []bcpb.PublicKey{kps[0].PublicKey, kps[1].PublicKey, kps[1].PublicKey}
+	blk, err = bc.NewNextBlock(kps[0].PublicKey, signers, nil, 3, 1, 2)
+	assert.Nil(t, err)
+	bsig, _ = kps[0].Sign(blk.Digest)
+	blk.Sign(kps[0].PublicKey, bsig)
+	id, err = bc.Append(blk, nil)
+	assert.Nil(t, err)
+
+	cd = bcpb.CommitDigest(bc.Hasher(), id)
+	sig, _ := kps[1].Sign(cd)
+	cert = &bcpb.CommitCertificate{Block: id, Signatures: [][]byte{nil, sig, sig}}
+	assert.Nil(t, bc.blk.st.SetCertificate(nil, id, cert))
+	assert.Equal(t, ErrCommitQuorum, bc.Commit(id))
+}
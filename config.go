@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"crypto/elliptic"
+	"errors"
 
 	"github.com/hexablock/hasher"
 )
@@ -18,6 +19,20 @@ type Config struct {
 	BlockStorage BlockStorage
 	TxStorage    TxStorage
 	DataKeyIndex DataKeyIndex
+	UTXOIndex    UTXOIndex
+	QueryIndex   QueryIndex
+
+	// Batcher creates write batches spanning the above stores.  It is
+	// required and must be backed by the same database as the stores
+	Batcher Batcher
+
+	// PruneDepth enables pruned mode when non-zero.  The bodies of txs whose
+	// outputs have all been spent by blocks at least this many blocks below
+	// the last block are removed.  Rewinding or reorging back more than this
+	// many blocks fails with ErrPruneDepth.  DataKey history versions of
+	// pruned txs have no output and the main chain cannot be exported once any
+	// of its txs are pruned
+	PruneDepth uint32
 }
 
 // DefaultConfig returns a config with the default hasher and elliptic curve
@@ -27,3 +42,26 @@ func DefaultConfig() *Config {
 		Curve:  elliptic.P256(),
 	}
 }
+
+// validate checks all required fields are set
+func (conf *Config) validate() error {
+	switch {
+	case conf.Hasher == nil:
+		return errors.New("config: Hasher required")
+	case conf.Curve == nil:
+		return errors.New("config: Curve required")
+	case conf.BlockStorage == nil:
+		return errors.New("config: BlockStorage required")
+	case conf.TxStorage == nil:
+		return errors.New("config: TxStorage required")
+	case conf.DataKeyIndex == nil:
+		return errors.New("config: DataKeyIndex required")
+	case conf.UTXOIndex == nil:
+		return errors.New("config: UTXOIndex required")
+	case conf.QueryIndex == nil:
+		return errors.New("config: QueryIndex required")
+	case conf.Batcher == nil:
+		return errors.New("config: Batcher required")
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package blockchain
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+// EventType is the type of a ledger event.  Types can be or'ed together to
+// build a filter
+type EventType uint8
+
+const (
+	// EventBlockAppended is emitted when a block is appended to the ledger
+	EventBlockAppended EventType = 1 << iota
+	// EventBlockCommitted is emitted when a block becomes part of the main
+	// chain
+	EventBlockCommitted
+	// EventBlockReverted is emitted when a block is removed from the main chain
+	// by a reorg or rewind
+	EventBlockReverted
+	// EventTxIndexed is emitted for each tx of a committed block once its
+	// outputs have been indexed
+	EventTxIndexed
+	// EventDataKeyUpdated is emitted each time a DataKey changes state
+	EventDataKeyUpdated
+)
+
+// Event is a change to the ledger.  Events are shared between subscribers and
+// must not be modified
+type Event struct {
+	Type EventType
+	// Block the event belongs to.  This is always set
+	Block *bcpb.Block
+	// Tx that was indexed.  Only set for EventTxIndexed
+	Tx *bcpb.Tx
+	// DataKey with its previous and new output.  Only set for
+	// EventDataKeyUpdated.  Old is nil if the DataKey was created and New is
+	// nil if it was removed
+	DataKey bcpb.DataKey
+	Old     *bcpb.TxOutput
+	New     *bcpb.TxOutput
+}
+
+// EventFilter selects the events delivered to a subscription
+type EventFilter struct {
+	// Event types to deliver.  Zero delivers all types
+	Types EventType
+	// Only deliver EventDataKeyUpdated events for DataKeys with this prefix.
+	// Other event types are not affected
+	DataKeyPrefix bcpb.DataKey
+}
+
+func (filter EventFilter) match(ev *Event) bool {
+	if filter.Types != 0 && filter.Types&ev.Type == 0 {
+		return false
+	}
+
+	if ev.Type == EventDataKeyUpdated {
+		return bytes.HasPrefix(ev.DataKey, filter.DataKeyPrefix)
+	}
+
+	return true
+}
+
+// Subscription delivers ledger events matching its filter.  Events are
+// published only once the corresponding change has been persisted.  Publishing
+// never blocks the ledger: when the buffer of a subscription is full new events
+// for it are dropped and counted.  Subscribers that need every event should
+// size the buffer accordingly and check Dropped.
+type Subscription struct {
+	filter EventFilter
+	ch     chan Event
+	bus    *eventBus
+
+	dropped uint64
+}
+
+// Events returns the channel events are delivered on.  It is closed when the
+// subscription is closed
+func (sub *Subscription) Events() <-chan Event {
+	return sub.ch
+}
+
+// Dropped returns the number of events dropped due to a full buffer
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// Close stops delivery of events and closes the event channel
+func (sub *Subscription) Close() {
+	sub.bus.unsubscribe(sub)
+}
+
+func (sub *Subscription) deliver(ev Event) {
+	if !sub.filter.match(&ev) {
+		return
+	}
+
+	select {
+	case sub.ch <- ev:
+	default:
+		atomic.AddUint64(&sub.dropped, 1)
+	}
+}
+
+// eventBus fans out events to all subscriptions
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[*Subscription]struct{})}
+}
+
+func (bus *eventBus) subscribe(filter EventFilter, size int) *Subscription {
+	if size < 1 {
+		size = 1
+	}
+
+	sub := &Subscription{
+		filter: filter,
+		ch:     make(chan Event, size),
+		bus:    bus,
+	}
+
+	bus.mu.Lock()
+	bus.subs[sub] = struct{}{}
+	bus.mu.Unlock()
+
+	return sub
+}
+
+func (bus *eventBus) unsubscribe(sub *Subscription) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if _, ok := bus.subs[sub]; ok {
+		delete(bus.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// active returns true if there are any subscriptions
+func (bus *eventBus) active() bool {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	return len(bus.subs) > 0
+}
+
+func (bus *eventBus) publish(events ...Event) {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+
+	for _, ev := range events {
+		for sub := range bus.subs {
+			sub.deliver(ev)
+		}
+	}
+}
+
+// Subscribe returns a new subscription delivering events matching the filter.
+// size is the number of events buffered for the subscription before events
+// are dropped
+func (bc *Blockchain) Subscribe(filter EventFilter, size int) *Subscription {
+	return bc.events.subscribe(filter, size)
+}
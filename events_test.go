@@ -0,0 +1,79 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+func Test_Blockchain_Subscribe(t *testing.T) {
+	conf := testBlockchainConfPrefix("events/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	all := bc.Subscribe(EventFilter{}, 64)
+	keys := bc.Subscribe(EventFilter{Types: EventDataKeyUpdated, DataKeyPrefix: bcpb.DataKey("ev:b")}, 64)
+	small := bc.Subscribe(EventFilter{}, 1)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "ev:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	txs := []*bcpb.Tx{testUpdateTx(t, bc, "ev:a", "1"), testBaseTx(bc, "ev:b")}
+	b1 := testSignedBlock(bc, genesis, txs, kp)
+	_, err := bc.Append(b1, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(b1.Digest))
+
+	// genesis: datakey, tx, committed.  b1: appended, 2 x (datakey, tx), committed
+	types := []EventType{
+		EventDataKeyUpdated, EventTxIndexed, EventBlockCommitted,
+		EventBlockAppended,
+		EventDataKeyUpdated, EventTxIndexed, EventDataKeyUpdated, EventTxIndexed, EventBlockCommitted,
+	}
+	events := make([]Event, len(types))
+	for i := range types {
+		events[i] = <-all.Events()
+		assert.Equal(t, types[i], events[i].Type)
+	}
+	assert.Equal(t, 0, len(all.Events()))
+	assert.Equal(t, uint64(0), all.Dropped())
+
+	// Created
+	assert.Nil(t, events[0].Old)
+	assert.NotNil(t, events[0].New)
+	// Updated
+	assert.Equal(t, bcpb.DataKey("ev:a"), events[4].DataKey)
+	assert.NotNil(t, events[4].Old)
+	assert.Equal(t, []byte("1"), events[4].New.Data)
+	assert.Equal(t, txs[0].Digest, events[5].Tx.Digest)
+	assert.Equal(t, b1.Digest, events[8].Block.Digest)
+
+	// Filtered
+	ev := <-keys.Events()
+	assert.Equal(t, bcpb.DataKey("ev:b"), ev.DataKey)
+	assert.Equal(t, 0, len(keys.Events()))
+
+	// Full buffer drops
+	assert.Equal(t, 1, len(small.Events()))
+	assert.Equal(t, uint64(len(types)-1), small.Dropped())
+
+	// Reverts are published
+	assert.Nil(t, bc.Rewind(0))
+	ev = <-keys.Events()
+	assert.Equal(t, bcpb.DataKey("ev:b"), ev.DataKey)
+	assert.NotNil(t, ev.Old)
+	assert.Nil(t, ev.New)
+
+	all.Close()
+	_, ok := <-all.Events()
+	for ok {
+		_, ok = <-all.Events()
+	}
+	all.Close()
+}
@@ -0,0 +1,141 @@
+package blockchain
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// ErrExecutedBlockReverted is returned when the last executed block is no
+// longer part of the main chain e.g. after a Reorg or Rewind
+var ErrExecutedBlockReverted = errors.New("executed block reverted")
+
+// Application is a state machine driven by the committed blocks of the ledger.
+// Each block is delivered as a call to BeginBlock, DeliverTx for each tx in
+// block order, EndBlock and finally Commit
+type Application interface {
+	// BeginBlock signals the start of a block
+	BeginBlock(*bcpb.Block) error
+	// DeliverTx delivers a single tx in the block
+	DeliverTx(*bcpb.Tx) error
+	// EndBlock signals all txs of the block have been delivered
+	EndBlock(*bcpb.Block) error
+	// Commit persists the application state returning the app hash
+	Commit() ([]byte, error)
+}
+
+// Executor executes committed blocks against an Application.  Progress is
+// tracked using the last executed pointer in the BlockStorage, which is only
+// advanced once the application has committed the block.  An application must
+// be able to handle the last block being delivered again if the process stops
+// between its Commit and the pointer being advanced.
+type Executor struct {
+	bc  *Blockchain
+	app Application
+
+	mu sync.Mutex
+	// App hash from the last Commit
+	appHash []byte
+}
+
+// NewExecutor returns a new Executor for the application
+func NewExecutor(bc *Blockchain, app Application) *Executor {
+	return &Executor{bc: bc, app: app}
+}
+
+// AppHash returns the app hash returned by the last application Commit.  It is
+// nil until a block has been executed by this Executor
+func (ex *Executor) AppHash() []byte {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return ex.appHash
+}
+
+// Execute executes all committed blocks after the last executed block in
+// height order.  If no block has been executed execution starts at genesis.
+// It returns the number of blocks executed stopping at the first error.
+func (ex *Executor) Execute() (int, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	blocks, err := ex.pending()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, blk := range blocks {
+		if err = ex.execute(blk); err != nil {
+			return i, err
+		}
+	}
+
+	return len(blocks), nil
+}
+
+func (ex *Executor) execute(blk *bcpb.Block) error {
+	txs, err := ex.bc.getBlockTxs(blk)
+	if err != nil {
+		return err
+	}
+
+	if err = ex.app.BeginBlock(blk); err != nil {
+		return err
+	}
+
+	for _, tx := range txs {
+		if err = ex.app.DeliverTx(tx); err != nil {
+			return err
+		}
+	}
+
+	if err = ex.app.EndBlock(blk); err != nil {
+		return err
+	}
+
+	hash, err := ex.app.Commit()
+	if err != nil {
+		return err
+	}
+	ex.appHash = hash
+
+	return ex.bc.blk.st.SetLastExec(nil, blk.Digest)
+}
+
+// pending returns the committed blocks after the last executed block in
+// ascending height order
+func (ex *Executor) pending() ([]*bcpb.Block, error) {
+	lid, last := ex.bc.blk.st.Last()
+	if last == nil {
+		// Nothing committed
+		return nil, nil
+	}
+
+	eid, exec := ex.bc.blk.st.LastExec()
+
+	blocks := make([]*bcpb.Block, 0)
+	for id := lid; exec == nil || !id.Equal(eid); {
+		blk := ex.bc.blk.get(id)
+		if blk == nil {
+			return nil, stores.ErrBlockNotFound
+		}
+
+		if exec != nil && blk.Header.Height <= exec.Header.Height {
+			return nil, ErrExecutedBlockReverted
+		}
+
+		blocks = append(blocks, blk)
+		if blk.Header.Height == 0 {
+			break
+		}
+		id = blk.Header.PrevBlock
+	}
+
+	// Reverse to ascending height order
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,108 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+type testApp struct {
+	calls  []string
+	blocks []uint32
+	txs    int
+	fail   error
+}
+
+func (app *testApp) BeginBlock(blk *bcpb.Block) error {
+	app.calls = append(app.calls, "begin")
+	app.blocks = append(app.blocks, blk.Header.Height)
+	return app.fail
+}
+
+func (app *testApp) DeliverTx(tx *bcpb.Tx) error {
+	app.calls = append(app.calls, "deliver")
+	app.txs++
+	return nil
+}
+
+func (app *testApp) EndBlock(blk *bcpb.Block) error {
+	app.calls = append(app.calls, "end")
+	return nil
+}
+
+func (app *testApp) Commit() ([]byte, error) {
+	app.calls = append(app.calls, "commit")
+	return []byte{byte(app.txs)}, nil
+}
+
+func Test_Executor(t *testing.T) {
+	conf := testBlockchainConfPrefix("executor/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	app := &testApp{}
+	ex := NewExecutor(bc, app)
+
+	// Nothing committed
+	n, err := ex.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "ex:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	txs := []*bcpb.Tx{testUpdateTx(t, bc, "ex:a", "1"), testBaseTx(bc, "ex:b")}
+	b1 := testSignedBlock(bc, genesis, txs, kp)
+	_, err = bc.Append(b1, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(b1.Digest))
+
+	n, err = ex.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []uint32{0, 1}, app.blocks)
+	assert.Equal(t, []string{"begin", "deliver", "end", "commit", "begin", "deliver", "deliver", "end", "commit"}, app.calls)
+	assert.Equal(t, []byte{3}, ex.AppHash())
+
+	eid, _ := bc.blk.st.LastExec()
+	assert.Equal(t, b1.Digest, eid)
+
+	// Resumes from the last executed block after a restart
+	txs = []*bcpb.Tx{testUpdateTx(t, bc, "ex:b", "2")}
+	b2 := testSignedBlock(bc, b1, txs, kp)
+	_, err = bc.Append(b2, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(b2.Digest))
+
+	app2 := &testApp{fail: errors.New("app failure")}
+	ex = NewExecutor(bc, app2)
+	n, err = ex.Execute()
+	assert.Equal(t, app2.fail, err)
+	assert.Equal(t, 0, n)
+	eid, _ = bc.blk.st.LastExec()
+	assert.Equal(t, b1.Digest, eid)
+
+	app2.fail = nil
+	n, err = ex.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []uint32{2, 2}, app2.blocks)
+
+	// Reverting an executed block is detected
+	ctxs := []*bcpb.Tx{testBaseTx(bc, "ex:c")}
+	c2 := testSignedBlock(bc, b1, ctxs, kp, kp2)
+	_, err = bc.Append(c2, ctxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Reorg(c2.Digest))
+
+	_, err = ex.Execute()
+	assert.Equal(t, ErrExecutedBlockReverted, err)
+}
@@ -0,0 +1,312 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+const (
+	// exportMagic starts every export stream
+	exportMagic = "BCEX"
+	// ExportVersion is the version of the export stream format
+	ExportVersion uint32 = 1
+	// maxFrameSize bounds a single frame read during an import
+	maxFrameSize = 64 << 20
+)
+
+var (
+	// ErrExportVersion is returned when importing a stream that is not an
+	// export or is of an unsupported version
+	ErrExportVersion = errors.New("unsupported export stream")
+	// ErrExportPruned is returned when exporting a ledger with main chain txs
+	// that have been pruned as the stream could not be imported
+	ErrExportPruned = errors.New("cannot export pruned txs")
+	// ErrImportSnapshot is returned when importing a stream exported from a
+	// ledger restored from a snapshot into a ledger not restored from it
+	ErrImportSnapshot = errors.New("import requires the snapshot of the first block")
+
+	errFrameTooLarge      = errors.New("frame too large")
+	errInvalidBlockRecord = errors.New("invalid block record")
+)
+
+// ImportError is returned when an import fails.  Height is that of the first
+// block that could not be imported.  All blocks below it have been committed
+type ImportError struct {
+	Height uint32
+	Err    error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("import failed at height %d: %v", e.Height, e.Err)
+}
+
+// Export writes the main chain from genesis up to the last committed block to
+// w.  The stream starts with the magic bytes and version followed by a record
+// per block in height order.  A record is the block, its commit certificate and
+// each of its txs in block order.  Every item is prefixed with its uint32
+// length.  A block without a certificate has an empty one.  On a ledger restored
+// from a snapshot the stream starts at the snapshot block.  ErrExportPruned is
+// returned before anything is written if any of the txs has been pruned
+func (bc *Blockchain) Export(w io.Writer) error {
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return stores.ErrBlockNotFound
+	}
+
+	path, err := bc.blk.chain(lid)
+	if err != nil {
+		return err
+	}
+
+	if err = bc.checkUnpruned(path); err != nil {
+		return err
+	}
+
+	hdr := make([]byte, len(exportMagic)+4)
+	copy(hdr, exportMagic)
+	binary.BigEndian.PutUint32(hdr[len(exportMagic):], ExportVersion)
+	if _, err = w.Write(hdr); err != nil {
+		return err
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		if err = bc.exportBlock(w, path[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkUnpruned returns ErrExportPruned if the body of any of the txs of the
+// blocks has been pruned
+func (bc *Blockchain) checkUnpruned(blks []*bcpb.Block) error {
+	for _, blk := range blks {
+		for _, tid := range blk.Txs {
+			_, err := bc.tx.Get(tid)
+			if err == stores.ErrPruned {
+				return ErrExportPruned
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (bc *Blockchain) exportBlock(w io.Writer, blk *bcpb.Block) error {
+	b, err := blk.Marshal()
+	if err != nil {
+		return err
+	}
+	if err = writeFrame(w, b); err != nil {
+		return err
+	}
+
+	var cb []byte
+	cert, err := bc.blk.st.Certificate(blk.Digest)
+	if err == nil {
+		if cb, err = cert.Marshal(); err != nil {
+			return err
+		}
+	} else if err != stores.ErrCertificateNotFound {
+		return err
+	}
+	if err = writeFrame(w, cb); err != nil {
+		return err
+	}
+
+	for _, tid := range blk.Txs {
+		tx, err := bc.tx.Get(tid)
+		if err != nil {
+			return err
+		}
+		if b, err = tx.Marshal(); err != nil {
+			return err
+		}
+		if err = writeFrame(w, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import reads a stream produced by Export into an empty ledger.  Each block is
+// validated and committed as if it were received from the network.  A stream
+// exported from a ledger restored from a snapshot starts at the snapshot block
+// and can only be imported into a ledger that has just been restored from the
+// same snapshot, see Restore.  The snapshot block is then skipped.  If given,
+// progress is called with the height of each block once committed.  Any failure
+// is returned as an *ImportError
+func (bc *Blockchain) Import(r io.Reader, progress func(height uint32)) error {
+	gid, gen := bc.blk.st.Genesis()
+	if gen != nil {
+		if lid, _ := bc.blk.st.Last(); !lid.Equal(gid) || gen.Header.Height == 0 {
+			return ErrStoreNotEmpty
+		}
+	}
+
+	hdr := make([]byte, len(exportMagic)+4)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return &ImportError{Err: err}
+	}
+	if !bytes.Equal(hdr[:len(exportMagic)], []byte(exportMagic)) ||
+		binary.BigEndian.Uint32(hdr[len(exportMagic):]) != ExportVersion {
+		return &ImportError{Err: ErrExportVersion}
+	}
+
+	var height uint32
+	if gen != nil {
+		height = gen.Header.Height
+	}
+
+	for first := true; ; first = false {
+		blk, cert, txs, err := readBlockRecord(r)
+		if err == io.EOF && !first {
+			return nil
+		} else if err != nil {
+			return &ImportError{Height: height, Err: err}
+		}
+
+		if first {
+			// The stream starts at the genesis or snapshot block
+			if gen == nil && blk.Header.Height != 0 {
+				return &ImportError{Height: blk.Header.Height, Err: ErrImportSnapshot}
+			} else if gen != nil && !blk.Header.Hash(bc.h).Equal(gid) {
+				return &ImportError{Height: height, Err: ErrImportSnapshot}
+			}
+		}
+
+		if blk.Header.Height != height {
+			return &ImportError{Height: height, Err: errHeightMismatch}
+		}
+
+		// The snapshot block has been restored
+		if first && gen != nil {
+			height++
+			continue
+		}
+
+		if err = bc.importBlock(blk, cert, txs, first); err != nil {
+			return &ImportError{Height: height, Err: err}
+		}
+
+		if progress != nil {
+			progress(height)
+		}
+		height++
+	}
+}
+
+// importBlock validates and commits a single block
+func (bc *Blockchain) importBlock(blk *bcpb.Block, cert *bcpb.CommitCertificate, txs []*bcpb.Tx, genesis bool) error {
+	if len(txs) != len(blk.Txs) {
+		return errInvalidBlockRecord
+	}
+
+	var (
+		id  = blk.Header.Hash(bc.h)
+		err error
+	)
+	if genesis {
+		err = bc.SetGenesis(blk, txs)
+	} else {
+		_, err = bc.Append(blk, txs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cert != nil {
+		if !cert.Block.Equal(id) {
+			return errInvalidBlockRecord
+		}
+		if err = bc.blk.st.SetCertificate(nil, id, cert); err != nil {
+			return err
+		}
+	}
+
+	return bc.Commit(id)
+}
+
+// readBlockRecord reads the block, certificate and txs of a single record.  It
+// returns io.EOF if the stream ends before the record
+func readBlockRecord(r io.Reader) (*bcpb.Block, *bcpb.CommitCertificate, []*bcpb.Tx, error) {
+	b, err := readFrame(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blk := &bcpb.Block{}
+	if err = blk.Unmarshal(b); err != nil {
+		return nil, nil, nil, err
+	}
+	if blk.Header == nil {
+		return nil, nil, nil, errInvalidBlockRecord
+	}
+
+	var cert *bcpb.CommitCertificate
+	if b, err = readFrame(r); err != nil {
+		return nil, nil, nil, unexpectedEOF(err)
+	}
+	if len(b) > 0 {
+		cert = &bcpb.CommitCertificate{}
+		if err = cert.Unmarshal(b); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	txs := make([]*bcpb.Tx, len(blk.Txs))
+	for i := range txs {
+		if b, err = readFrame(r); err != nil {
+			return nil, nil, nil, unexpectedEOF(err)
+		}
+		txs[i] = &bcpb.Tx{}
+		if err = txs[i].Unmarshal(b); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return blk, cert, txs, nil
+}
+
+func writeFrame(w io.Writer, b []byte) error {
+	l := make([]byte, 4)
+	binary.BigEndian.PutUint32(l, uint32(len(b)))
+	if _, err := w.Write(l); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// readFrame reads a length prefixed frame.  It returns io.EOF only if the
+// stream ends before the frame
+func readFrame(r io.Reader) ([]byte, error) {
+	l := make([]byte, 4)
+	if _, err := io.ReadFull(r, l); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(l)
+	if n > maxFrameSize {
+		return nil, errFrameTooLarge
+	}
+
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, unexpectedEOF(err)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+func Test_Blockchain_ExportImport(t *testing.T) {
+	conf := testBlockchainConfPrefix("export/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	signers := []bcpb.PublicKey{kp.PublicKey}
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "exp:a", "exp:b")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// A block requiring a commit certificate
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "exp:a", "1")}
+	a1, err := bc.NewNextBlock(kp.PublicKey, signers, atxs, 1, 1, 1)
+	assert.Nil(t, err)
+	sig, _ := kp.Sign(a1.Digest)
+	assert.Nil(t, a1.Sign(kp.PublicKey, sig))
+	_, err = bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	testCommit(t, bc, a1.Digest, kp)
+
+	a2txs := []*bcpb.Tx{testUpdateTx(t, bc, "exp:b", "2"), testBaseTx(bc, "exp:c")}
+	a2 := testSignedBlock(bc, a1, a2txs, kp)
+	_, err = bc.Append(a2, a2txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a2.Digest))
+
+	var buf bytes.Buffer
+	assert.Nil(t, bc.Export(&buf))
+	stream := buf.Bytes()
+
+	bc2 := New(testBlockchainConfPrefix("import/"))
+	heights := make([]uint32, 0)
+	err = bc2.Import(bytes.NewReader(stream), func(h uint32) {
+		heights = append(heights, h)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 1, 2}, heights)
+	assert.Equal(t, a2.Digest, bc2.Last().Digest)
+
+	out, err := bc2.GetTXOByDataKey(bcpb.DataKey("exp:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), out.Data)
+	_, err = bc2.CommitCertificate(a1.Digest)
+	assert.Nil(t, err)
+
+	assert.Equal(t, ErrStoreNotEmpty, bc2.Import(bytes.NewReader(stream), nil))
+
+	// Unknown format
+	bc3 := New(testBlockchainConfPrefix("import-bad/"))
+	err = bc3.Import(bytes.NewReader([]byte("BCEX\x00\x00\x00\x09")), nil)
+	assert.Equal(t, ErrExportVersion, err.(*ImportError).Err)
+
+	// A truncated stream reports the first height not imported
+	err = bc3.Import(bytes.NewReader(stream[:len(stream)-10]), nil)
+	ierr, ok := err.(*ImportError)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), ierr.Height)
+	assert.Equal(t, a1.Digest, bc3.Last().Digest)
+}
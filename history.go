@@ -0,0 +1,117 @@
+package blockchain
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// DataKeyVersion is a state a DataKey was set to by a block on the main chain
+type DataKeyVersion struct {
+	// Height and timestamp of the block
+	Height    uint32
+	Timestamp int64
+	// Tx and output index of the state.  For a deleted DataKey this is the
+	// deleting tx and stores.TombstoneIndex
+	Ref   bcpb.Digest
+	Index int32
+	// Output of the state.  It is nil if the DataKey was deleted or the tx has
+	// been pruned
+	TxOutput *bcpb.TxOutput
+	// True if the body of the tx has been pruned, see Config.PruneDepth
+	Pruned bool
+}
+
+// Deleted returns true if the DataKey was deleted
+func (v *DataKeyVersion) Deleted() bool {
+	return v.Index == stores.TombstoneIndex
+}
+
+// DataKeyHistory returns every state the DataKey has been set to on the main
+// chain oldest first.  stores.ErrDataKeyNotFound is returned if the DataKey has
+// never been written.  Versions whose tx has been pruned are returned without
+// their output
+func (bc *Blockchain) DataKeyHistory(key bcpb.DataKey) ([]*DataKeyVersion, error) {
+	entries := make([]stores.HistoryEntry, 0)
+	err := bc.tx.dki.History(key, func(e stores.HistoryEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, stores.ErrDataKeyNotFound
+	}
+
+	versions := make([]*DataKeyVersion, len(entries))
+	for i, e := range entries {
+		if versions[i], err = bc.dataKeyVersion(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// GetTXOByDataKeyAt returns the TxOutput of the DataKey as of the block at the
+// given height on the main chain.  stores.ErrDataKeyNotFound is returned if the
+// DataKey did not exist at the height, stores.ErrDataKeyDeleted if it had
+// been deleted and stores.ErrPruned if the tx holding the output has been
+// pruned
+func (bc *Blockchain) GetTXOByDataKeyAt(key bcpb.DataKey, height uint32) (*bcpb.TxOutput, error) {
+	var (
+		last  stores.HistoryEntry
+		found bool
+	)
+
+	err := bc.tx.dki.History(key, func(e stores.HistoryEntry) bool {
+		if e.Height > height {
+			return false
+		}
+		last, found = e, true
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, stores.ErrDataKeyNotFound
+	} else if last.Deleted() {
+		return nil, stores.ErrDataKeyDeleted
+	}
+
+	v, err := bc.dataKeyVersion(last)
+	if err != nil {
+		return nil, err
+	} else if v.Pruned {
+		return nil, stores.ErrPruned
+	}
+	return v.TxOutput, nil
+}
+
+func (bc *Blockchain) dataKeyVersion(e stores.HistoryEntry) (*DataKeyVersion, error) {
+	v := &DataKeyVersion{
+		Height:    e.Height,
+		Timestamp: e.Timestamp,
+		Ref:       e.Ref,
+		Index:     e.Index,
+	}
+	if e.Deleted() {
+		return v, nil
+	}
+
+	tx, err := bc.tx.Get(e.Ref)
+	if err == stores.ErrPruned {
+		v.Pruned = true
+		return v, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if e.Index < 0 || int(e.Index) >= len(tx.Outputs) {
+		return nil, errInvalidOutputIndex
+	}
+	v.TxOutput = tx.Outputs[e.Index]
+
+	return v, nil
+}
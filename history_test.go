@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func Test_Blockchain_DataKeyHistory(t *testing.T) {
+	conf := testBlockchainConfPrefix("history/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	key := bcpb.DataKey("hist:a")
+
+	_, err := bc.DataKeyHistory(key)
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "hist:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "hist:a", "1")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp)
+	_, err = bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	// Delete
+	txi, _ := bc.NewTxInput(key)
+	dtx := bcpb.NewTx()
+	dtx.AddInput(txi)
+	dtx.SetDigest(bc.Hasher())
+	a2txs := []*bcpb.Tx{dtx}
+	a2 := testSignedBlock(bc, a1, a2txs, kp)
+	_, err = bc.Append(a2, a2txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a2.Digest))
+
+	versions, err := bc.DataKeyHistory(key)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(versions))
+	assert.Equal(t, uint32(1), versions[1].Height)
+	assert.Equal(t, a1.Header.Timestamp, versions[1].Timestamp)
+	assert.Equal(t, []byte("1"), versions[1].TxOutput.Data)
+	assert.True(t, versions[2].Deleted())
+	assert.Nil(t, versions[2].TxOutput)
+
+	out, err := bc.GetTXOByDataKeyAt(key, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, out.Data)
+	out, err = bc.GetTXOByDataKeyAt(key, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+	_, err = bc.GetTXOByDataKeyAt(key, 2)
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+	_, err = bc.GetTXOByDataKeyAt(bcpb.DataKey("hist:b"), 2)
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+
+	// Rewinding drops the history of the reverted blocks
+	assert.Nil(t, bc.Rewind(1))
+	versions, err = bc.DataKeyHistory(key)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(versions))
+	out, err = bc.GetTXOByDataKeyAt(key, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+}
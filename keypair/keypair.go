@@ -56,6 +56,22 @@ func (w *KeyPair) setPublicKey() {
 	w.PublicKey = bcpb.PublicKey(pubkey)
 }
 
+// curveByteSize returns the byte size of a coordinate on the curve
+func curveByteSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+// padBytes left pads b with zeros to the given size.  This keeps both halves of
+// concatenated values the same length
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
 // Algorithm returns the keypair algorithm
 func (w KeyPair) Algorithm() []byte {
 	return []byte(fmt.Sprintf("ecdsa%d", w.curve.Params().BitSize))
@@ -66,11 +82,14 @@ func (w KeyPair) Address() []byte {
 	return w.PublicKey.Address(w.h)
 }
 
-// Sign signs the digest and returns the signature
+// Sign signs the digest and returns the signature.  Both r and s are padded to
+// the curve size so the signature always splits evenly.  This only changes the
+// encoding of signatures that previously failed to verify
 func (w KeyPair) Sign(digest bcpb.Digest) ([]byte, error) {
 	r, s, err := ecdsa.Sign(rand.Reader, &w.PrivateKey, digest)
 	if err == nil {
-		return append(r.Bytes(), s.Bytes()...), nil
+		size := curveByteSize(w.PrivateKey.Curve)
+		return append(padBytes(r.Bytes(), size), padBytes(s.Bytes(), size)...), nil
 	}
 
 	return nil, err
@@ -85,17 +104,64 @@ func (w KeyPair) VerifySignature(digest bcpb.Digest, signature []byte) bool {
 	r.SetBytes(signature[:(sigLen / 2)])
 	s.SetBytes(signature[(sigLen / 2):])
 
-	pubkey := w.PublicKey
+	return ecdsa.Verify(w.rawPublicKey(), digest, &r, &s)
+}
+
+// rawPublicKey returns the ecdsa public key.  Public keys are the unpadded X and
+// Y coordinates so a coordinate with leading zero bytes is shorter than the
+// other.  Each way of splitting such a key is tried and the one on the curve
+// is used
+func (w KeyPair) rawPublicKey() *ecdsa.PublicKey {
+	var (
+		pubkey = w.PublicKey
+		keyLen = len(pubkey)
+		size   = curveByteSize(w.curve)
+	)
+
+	for i := keyLen - size; keyLen < 2*size && i <= size && i <= keyLen; i++ {
+		if i < 0 {
+			continue
+		}
+		x := new(big.Int).SetBytes(pubkey[:i])
+		y := new(big.Int).SetBytes(pubkey[i:])
+		if w.curve.IsOnCurve(x, y) {
+			return &ecdsa.PublicKey{Curve: w.curve, X: x, Y: y}
+		}
+	}
+
+	x := new(big.Int).SetBytes(pubkey[:(keyLen / 2)])
+	y := new(big.Int).SetBytes(pubkey[(keyLen / 2):])
+	return &ecdsa.PublicKey{Curve: w.curve, X: x, Y: y}
+}
 
-	x := big.Int{}
-	y := big.Int{}
-	keyLen := len(pubkey)
-	x.SetBytes(pubkey[:(keyLen / 2)])
-	y.SetBytes(pubkey[(keyLen / 2):])
+// CountSignatures returns the number of valid signatures of the digest.  Each
+// signature is verified against the public key at the same index.  Empty and
+// missing signatures are skipped and a public key listed more than once is
+// only counted once
+func CountSignatures(curve elliptic.Curve, h hasher.Hasher, digest bcpb.Digest,
+	pubkeys []bcpb.PublicKey, signatures [][]byte) int32 {
+
+	var (
+		c    int32
+		seen = make(map[string]struct{}, len(pubkeys))
+	)
+	for i := range pubkeys {
+		if i >= len(signatures) || len(signatures[i]) == 0 {
+			continue
+		}
+		if _, ok := seen[string(pubkeys[i])]; ok {
+			continue
+		}
 
-	rawPubKey := ecdsa.PublicKey{Curve: w.curve, X: &x, Y: &y}
+		kp := New(curve, h)
+		kp.PublicKey = pubkeys[i]
+		if kp.VerifySignature(digest, signatures[i]) {
+			seen[string(pubkeys[i])] = struct{}{}
+			c++
+		}
+	}
 
-	return ecdsa.Verify(&rawPubKey, digest, &r, &s)
+	return c
 }
 
 // Save x509 marshals the key and writes it to the given path
@@ -42,3 +42,52 @@ func Test_FromFile_error(t *testing.T) {
 	_, err := FromFile("foo/barbadfd/dfdf")
 	assert.NotNil(t, err)
 }
+
+func Test_CountSignatures(t *testing.T) {
+	curve, h := elliptic.P256(), hasher.Default()
+	kp1, _ := Generate(curve, h)
+	kp2, _ := Generate(curve, h)
+
+	digest := bcpb.Digest("xxxx")
+	sig1, _ := kp1.Sign(digest)
+	sig2, _ := kp2.Sign(digest)
+
+	pubkeys := []bcpb.PublicKey{kp1.PublicKey, kp2.PublicKey}
+	assert.Equal(t, int32(2), CountSignatures(curve, h, digest, pubkeys, [][]byte{sig1, sig2}))
+	assert.Equal(t, int32(1), CountSignatures(curve, h, digest, pubkeys, [][]byte{sig1, nil}))
+	assert.Equal(t, int32(1), CountSignatures(curve, h, digest, pubkeys, [][]byte{sig1}))
+	assert.Equal(t, int32(0), CountSignatures(curve, h, digest, pubkeys, [][]byte{sig2, sig1}))
+
+	// A repeated key counts once
+	pubkeys = []bcpb.PublicKey{kp1.PublicKey, kp1.PublicKey, kp2.PublicKey}
+	assert.Equal(t, int32(1), CountSignatures(curve, h, digest, pubkeys, [][]byte{sig1, sig1}))
+	assert.Equal(t, int32(2), CountSignatures(curve, h, digest, pubkeys, [][]byte{nil, sig1, sig2}))
+}
+
+func Test_KeyPair_ShortCoordinate(t *testing.T) {
+	curve, h := elliptic.P256(), hasher.Default()
+
+	// Public keys are not padded so a key with a short Y is shorter than 64
+	// bytes and cannot be split in half
+	var kp *KeyPair
+	for i := 0; i < 10000; i++ {
+		kp, _ = Generate(curve, h)
+		if len(kp.PrivateKey.PublicKey.Y.Bytes()) < 32 && len(kp.PrivateKey.PublicKey.X.Bytes()) == 32 {
+			break
+		}
+	}
+	assert.True(t, len(kp.PublicKey) < 64)
+
+	for i := 0; i < 10; i++ {
+		sig, err := kp.Sign(bcpb.Digest("xxxx"))
+		assert.Nil(t, err)
+		assert.Equal(t, 64, len(sig))
+		assert.True(t, kp.VerifySignature(bcpb.Digest("xxxx"), sig))
+	}
+
+	// Keys too short to hold a point do not verify
+	short := New(curve, h)
+	short.PublicKey = kp.PublicKey[:10]
+	sig, _ := kp.Sign(bcpb.Digest("xxxx"))
+	assert.False(t, short.VerifySignature(bcpb.Digest("xxxx"), sig))
+}
@@ -0,0 +1,623 @@
+package blockchain
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// ledgerWriter applies and reverts blocks on the main chain as part of a single
+// batch.  It tracks the DataKey changes made in the batch so subsequent reads
+// reflect them before the batch is committed
+type ledgerWriter struct {
+	bc    *Blockchain
+	batch stores.Batch
+
+	// DataKey states written as part of the batch
+	dks map[string]stores.UndoEntry
+	// Unspent outputs including the changes made as part of the batch
+	utxo *ledgerView
+
+	// Events to publish once the batch is committed.  They are only collected
+	// if there are subscribers
+	emit   bool
+	events []Event
+}
+
+func (bc *Blockchain) newLedgerWriter() *ledgerWriter {
+	return &ledgerWriter{
+		bc:    bc,
+		batch: bc.batcher.NewBatch(),
+		dks:   make(map[string]stores.UndoEntry),
+		utxo:  newLedgerView(bc.tx),
+		emit:  bc.events.active(),
+	}
+}
+
+// commit commits the batch and publishes the collected events
+func (w *ledgerWriter) commit() error {
+	err := w.batch.Commit()
+	if err == nil {
+		w.bc.events.publish(w.events...)
+	}
+	return err
+}
+
+func (w *ledgerWriter) event(ev Event) {
+	if w.emit {
+		w.events = append(w.events, ev)
+	}
+}
+
+// dataKeyEvent adds an EventDataKeyUpdated event for the key moving from the
+// old state to the new output
+func (w *ledgerWriter) dataKeyEvent(blk *bcpb.Block, old stores.UndoEntry, txo *bcpb.TxOutput) {
+	if !w.emit {
+		return
+	}
+
+	w.event(Event{
+		Type:    EventDataKeyUpdated,
+		Block:   blk,
+		DataKey: old.Key,
+		Old:     w.output(old),
+		New:     txo,
+	})
+}
+
+// output returns the output the DataKey state points to or nil if it does not
+// exist or has been deleted
+func (w *ledgerWriter) output(e stores.UndoEntry) *bcpb.TxOutput {
+	if e.Ref == nil || e.Deleted() {
+		return nil
+	}
+
+	tx, err := w.bc.tx.Get(e.Ref)
+	if err != nil || int(e.Index) >= len(tx.Outputs) {
+		return nil
+	}
+	return tx.Outputs[e.Index]
+}
+
+// getDataKey returns the current state of the DataKey.  The returned Ref is nil
+// if the DataKey does not exist
+func (w *ledgerWriter) getDataKey(key bcpb.DataKey) (stores.UndoEntry, error) {
+	if e, ok := w.dks[string(key)]; ok {
+		return e, nil
+	}
+
+	ref, i, err := w.bc.tx.dki.Get(key)
+	switch err {
+	case nil, stores.ErrDataKeyDeleted:
+		return stores.UndoEntry{Key: key, Ref: ref, Index: i}, nil
+	case stores.ErrDataKeyNotFound:
+		return stores.UndoEntry{Key: key}, nil
+	}
+
+	return stores.UndoEntry{}, err
+}
+
+// reindex moves the DataKey in the QueryIndex from the output of its current
+// state to the output of the next state
+func (w *ledgerWriter) reindex(key bcpb.DataKey, next stores.UndoEntry) error {
+	cur, err := w.getDataKey(key)
+	if err != nil {
+		return err
+	}
+
+	if txo := w.output(cur); txo != nil {
+		if err = w.bc.tx.qi.Remove(w.batch, key, txo); err != nil {
+			return err
+		}
+	}
+	if txo := w.output(next); txo != nil {
+		err = w.bc.tx.qi.Add(w.batch, key, txo)
+	}
+	return err
+}
+
+func (w *ledgerWriter) setDataKey(key bcpb.DataKey, ref bcpb.Digest, i int32) error {
+	if err := w.reindex(key, stores.UndoEntry{Key: key, Ref: ref, Index: i}); err != nil {
+		return err
+	}
+
+	err := w.bc.tx.dki.Set(w.batch, key, ref, i)
+	if err == nil {
+		w.dks[string(key)] = stores.UndoEntry{Key: key, Ref: ref, Index: i}
+	}
+	return err
+}
+
+func (w *ledgerWriter) deleteDataKey(key bcpb.DataKey, ref bcpb.Digest) error {
+	if err := w.reindex(key, stores.UndoEntry{Key: key}); err != nil {
+		return err
+	}
+
+	err := w.bc.tx.dki.Delete(w.batch, key, ref)
+	if err == nil {
+		w.dks[string(key)] = stores.UndoEntry{Key: key, Ref: ref, Index: stores.TombstoneIndex}
+	}
+	return err
+}
+
+func (w *ledgerWriter) removeDataKey(key bcpb.DataKey) error {
+	if err := w.reindex(key, stores.UndoEntry{Key: key}); err != nil {
+		return err
+	}
+
+	err := w.bc.tx.dki.Remove(w.batch, key)
+	if err == nil {
+		w.dks[string(key)] = stores.UndoEntry{Key: key}
+	}
+	return err
+}
+
+// apply indexes the outputs of all txs in the block, updates the unspent
+// outputs and makes it the last block and the main chain block at its height.
+// An input spending the current output of a DataKey that the tx does not emit
+// again deletes the DataKey.  The block must have reached its commit quorum.
+// The state of each DataKey prior to the block is recorded in the block's undo
+// log
+func (w *ledgerWriter) apply(id bcpb.Digest, blk *bcpb.Block) error {
+	if err := w.bc.checkCommitQuorum(id, blk); err != nil {
+		return err
+	}
+
+	txs, err := w.bc.getBlockTxs(blk)
+	if err != nil {
+		return err
+	}
+
+	var (
+		undo = make([]stores.UndoEntry, 0)
+		seen = make(map[string]struct{})
+		// Order of the DataKey writes in the block
+		seq uint32
+	)
+
+	// record returns the current state of the key recording it in the undo
+	// log if this is the first write in the block.  The new state is added to
+	// the history of the key
+	record := func(key bcpb.DataKey, ref bcpb.Digest, i int32) (stores.UndoEntry, error) {
+		prev, err := w.getDataKey(key)
+		if err != nil {
+			return prev, err
+		}
+
+		if _, ok := seen[string(key)]; !ok {
+			undo = append(undo, prev)
+			seen[string(key)] = struct{}{}
+		}
+
+		err = w.bc.tx.dki.AddHistory(w.batch, key, stores.HistoryEntry{
+			Height:    blk.Height(),
+			Timestamp: blk.Header.Timestamp,
+			Seq:       seq,
+			Ref:       ref,
+			Index:     i,
+		})
+		seq++
+
+		return prev, err
+	}
+
+	index := func(key bcpb.DataKey, tx *bcpb.Tx, i int) error {
+		prev, err := record(key, tx.Digest, int32(i))
+		if err != nil {
+			return err
+		}
+
+		if err = w.setDataKey(key, tx.Digest, int32(i)); err != nil {
+			return err
+		}
+		w.dataKeyEvent(blk, prev, tx.Outputs[i])
+		return nil
+	}
+
+	for pos, tx := range txs {
+		if err = w.validate(blk, tx); err != nil {
+			return err
+		}
+
+		keys, err := w.deletedDataKeys(tx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			prev, err := record(key, tx.Digest, stores.TombstoneIndex)
+			if err != nil {
+				return err
+			}
+			if err = w.deleteDataKey(key, tx.Digest); err != nil {
+				return err
+			}
+			w.dataKeyEvent(blk, prev, nil)
+		}
+
+		if err = w.spend(tx); err != nil {
+			return err
+		}
+
+		for i, txo := range tx.Outputs {
+			if err = index(txo.DataKey, tx, i); err != nil {
+				return err
+			}
+
+			// Record the height the new signer set becomes active at
+			if txo.DataKey.Equal(SignerSetKey) {
+				if err = w.setSignerSet(blk.Height()+1, txo); err != nil {
+					return err
+				}
+			}
+		}
+
+		loc := stores.TxLocation{Block: id, Height: blk.Height(), Index: int32(pos)}
+		if err = w.bc.tx.tx.SetLocation(w.batch, tx.Digest, loc); err != nil {
+			return err
+		}
+
+		w.event(Event{Type: EventTxIndexed, Block: blk, Tx: tx})
+	}
+
+	if err = w.bc.tx.dki.SetUndo(w.batch, id, undo); err != nil {
+		return err
+	}
+
+	if err = w.bc.blk.st.SetLast(w.batch, id); err != nil {
+		return err
+	}
+	if err = w.bc.blk.st.SetHeight(w.batch, blk.Height(), id); err != nil {
+		return err
+	}
+
+	w.event(Event{Type: EventBlockCommitted, Block: blk})
+	return nil
+}
+
+// validate checks the rules of the tx that depend on the DataKey state against
+// the state of the batch.  Blocks are validated against the main chain when
+// appended so these are checked again as blocks of another branch are applied
+func (w *ledgerWriter) validate(blk *bcpb.Block, tx *bcpb.Tx) error {
+	for _, in := range tx.Inputs {
+		if !in.IsBase() {
+			continue
+		}
+
+		key := baseTxDataKey(in)
+		if key == nil {
+			continue
+		}
+		cur, err := w.getDataKey(key)
+		if err != nil {
+			return err
+		}
+		if cur.Ref != nil && !cur.Deleted() {
+			return errDataKeyExists(key)
+		}
+	}
+
+	cur, err := w.getDataKey(SignerSetKey)
+	if err != nil {
+		return err
+	}
+	if cur.Deleted() {
+		return stores.ErrDataKeyDeleted
+	}
+
+	return checkSignerSetTx(tx, cur, blk.Height() == 0)
+}
+
+// spend removes the outputs spent by the tx from the UTXOIndex and adds its
+// outputs.  It fails if an output spent by the tx is not unspent
+func (w *ledgerWriter) spend(tx *bcpb.Tx) error {
+	for _, in := range tx.Inputs {
+		if in.IsBase() {
+			continue
+		}
+		if !w.utxo.unspent(in.Ref, in.Index) {
+			return errTxSpent
+		}
+
+		txo, err := w.spentOutput(in)
+		if err != nil {
+			return err
+		}
+		if err = w.bc.tx.utxo.Remove(w.batch, in.Ref, in.Index, txo); err != nil {
+			return err
+		}
+		w.utxo.set(in.Ref, in.Index, false)
+	}
+
+	for i, txo := range tx.Outputs {
+		if err := w.bc.tx.utxo.Add(w.batch, tx.Digest, int32(i), txo); err != nil {
+			return err
+		}
+		w.utxo.set(tx.Digest, int32(i), true)
+	}
+
+	return nil
+}
+
+// unspend reverses spend
+func (w *ledgerWriter) unspend(tx *bcpb.Tx) error {
+	for i, txo := range tx.Outputs {
+		if err := w.bc.tx.utxo.Remove(w.batch, tx.Digest, int32(i), txo); err != nil {
+			return err
+		}
+		w.utxo.set(tx.Digest, int32(i), false)
+	}
+
+	for _, in := range tx.Inputs {
+		if in.IsBase() {
+			continue
+		}
+
+		txo, err := w.spentOutput(in)
+		if err != nil {
+			return err
+		}
+		if err = w.bc.tx.utxo.Add(w.batch, in.Ref, in.Index, txo); err != nil {
+			return err
+		}
+		w.utxo.set(in.Ref, in.Index, true)
+	}
+
+	return nil
+}
+
+// setSignerSet records the signer set held by the output as active from the
+// height onwards
+func (w *ledgerWriter) setSignerSet(height uint32, txo *bcpb.TxOutput) error {
+	var set bcpb.SignerSet
+	if err := set.Unmarshal(txo.Data); err != nil {
+		return err
+	}
+	return w.bc.blk.st.SetSignerSet(w.batch, height, &set)
+}
+
+// spentOutput returns the output referenced by the input
+func (w *ledgerWriter) spentOutput(in *bcpb.TxInput) (*bcpb.TxOutput, error) {
+	ref, err := w.bc.tx.Get(in.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if in.Index < 0 || int(in.Index) >= len(ref.Outputs) {
+		return nil, errInvalidOutputIndex
+	}
+	return ref.Outputs[in.Index], nil
+}
+
+// deletedDataKeys returns the DataKeys deleted by the tx i.e. those whose
+// current output is spent by an input of the tx without the tx having an
+// output for the key
+func (w *ledgerWriter) deletedDataKeys(tx *bcpb.Tx) ([]bcpb.DataKey, error) {
+	keys := make([]bcpb.DataKey, 0)
+
+	for _, in := range tx.Inputs {
+		if in.IsBase() {
+			continue
+		}
+
+		txo, err := w.spentOutput(in)
+		if err != nil {
+			return nil, err
+		}
+
+		key := txo.DataKey
+		if hasDataKey(tx.Outputs, key) {
+			continue
+		}
+
+		cur, err := w.getDataKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if !cur.Deleted() && cur.Ref.Equal(in.Ref) && cur.Index == in.Index {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func hasDataKey(outs []*bcpb.TxOutput, key bcpb.DataKey) bool {
+	for _, txo := range outs {
+		if txo.DataKey.Equal(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// revert restores each DataKey written by the block to its state before the
+// block using the block's undo log.  The outputs spent by the block become
+// unspent again and the ones it created are removed.  The parent of the block
+// becomes the last block and the block is removed from the height index
+func (w *ledgerWriter) revert(id bcpb.Digest, blk *bcpb.Block) error {
+	undo, err := w.bc.tx.dki.Undo(id)
+	if err != nil {
+		return err
+	}
+
+	txs, err := w.bc.getBlockTxs(blk)
+	if err != nil {
+		return err
+	}
+	for i := len(txs) - 1; i >= 0; i-- {
+		if err = w.unspend(txs[i]); err != nil {
+			return err
+		}
+		if err = w.bc.tx.tx.RemoveLocation(w.batch, txs[i].Digest); err != nil {
+			return err
+		}
+		if hasDataKey(txs[i].Outputs, SignerSetKey) {
+			if err = w.bc.blk.st.RemoveSignerSet(w.batch, blk.Height()+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, e := range undo {
+		cur, err := w.getDataKey(e.Key)
+		if err != nil {
+			return err
+		}
+
+		if e.Ref == nil {
+			err = w.removeDataKey(e.Key)
+		} else if e.Deleted() {
+			err = w.deleteDataKey(e.Key, e.Ref)
+		} else {
+			err = w.setDataKey(e.Key, e.Ref, e.Index)
+		}
+
+		if err != nil {
+			return err
+		}
+		if err = w.bc.tx.dki.RemoveHistory(w.batch, e.Key, blk.Height()); err != nil {
+			return err
+		}
+		w.dataKeyEvent(blk, cur, w.output(e))
+	}
+
+	if err = w.bc.tx.dki.RemoveUndo(w.batch, id); err != nil {
+		return err
+	}
+
+	if err = w.bc.blk.st.SetLast(w.batch, blk.Header.PrevBlock); err != nil {
+		return err
+	}
+	if err = w.bc.blk.st.RemoveHeight(w.batch, blk.Height()); err != nil {
+		return err
+	}
+
+	w.event(Event{Type: EventBlockReverted, Block: blk})
+	return nil
+}
+
+// Reorg switches the main chain to the branch ending at the given block.  The
+// DataKeyIndex is rewound to the common ancestor of the last block and the
+// given block after which each block on the new branch is committed in order.
+// The txs of the new branch are checked against the state of the branch as it
+// is applied and the reorg fails if any of them is invalid on it.  All changes
+// are made atomically.  The blocks on the old branch are kept and may be
+// switched back to.  In pruned mode spent txs are pruned once the new branch is
+// committed and ErrPruneDepth is returned if more than PruneDepth blocks would
+// be reverted
+func (bc *Blockchain) Reorg(id bcpb.Digest) error {
+	lid, _ := bc.blk.st.Last()
+	if lid.Equal(id) {
+		return nil
+	}
+
+	if !bc.blk.st.Exists(id) {
+		return stores.ErrBlockNotFound
+	}
+
+	oldBranch, newBranch, err := bc.blk.branches(lid, id)
+	if err != nil {
+		return err
+	}
+	if err = bc.checkPruneDepth(uint32(len(oldBranch))); err != nil {
+		return err
+	}
+
+	w := bc.newLedgerWriter()
+	defer w.batch.Discard()
+
+	// Rewind to the common ancestor
+	for _, blk := range oldBranch {
+		if err = w.revert(blk.Digest, blk); err != nil {
+			return err
+		}
+	}
+
+	// Re-apply the new branch from the common ancestor up
+	for i := len(newBranch) - 1; i >= 0; i-- {
+		if err = w.apply(newBranch[i].Digest, newBranch[i]); err != nil {
+			return err
+		}
+	}
+
+	if err = w.commit(); err != nil || len(newBranch) == 0 {
+		return err
+	}
+
+	bc.sweep(newBranch[len(newBranch)-1].Height(), newBranch[0].Height())
+	return nil
+}
+
+// ForkChoice switches the main chain to the branch head with the highest
+// cumulative signature weight if it is heavier than the last block.  Heads
+// extending the main chain are committed.  It returns the digest of the last
+// block once the choice is made
+func (bc *Blockchain) ForkChoice() (bcpb.Digest, error) {
+	lid, _ := bc.blk.st.Last()
+
+	// The last pointer is the zero digest until genesis is committed
+	best, bw := lid, uint64(0)
+	if w, err := bc.blk.st.Weight(lid); err == nil {
+		bw = w
+	}
+
+	for _, tip := range bc.blk.st.Tips() {
+		w, err := bc.blk.st.Weight(tip)
+		if err != nil {
+			return lid, err
+		}
+
+		if w > bw {
+			best, bw = tip, w
+		}
+	}
+
+	if best.Equal(lid) {
+		return lid, nil
+	}
+
+	if err := bc.Reorg(best); err != nil {
+		return lid, err
+	}
+
+	return best, nil
+}
+
+// Rewind rolls the main chain back to the block at the given height.  Each
+// DataKey is restored to its state at that height using the undo logs.  As with
+// Reorg the rolled back blocks are kept as a side branch and may be switched
+// back to.  All changes are made atomically.  In pruned mode ErrPruneDepth is
+// returned if more than PruneDepth blocks would be rolled back.  As with Reorg
+// the last executed block is left in place so an Executor reports
+// ErrExecutedBlockReverted if it was rolled back
+func (bc *Blockchain) Rewind(height uint32) error {
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return stores.ErrBlockNotFound
+	}
+
+	if height > last.Header.Height {
+		return errHeightMismatch
+	} else if height == last.Header.Height {
+		return nil
+	}
+	if err := bc.checkPruneDepth(last.Header.Height - height); err != nil {
+		return err
+	}
+
+	w := bc.newLedgerWriter()
+	defer w.batch.Discard()
+
+	// Revert main chain blocks down to the height
+	blk := last
+	blk.Digest = lid
+	for blk.Header.Height > height {
+		if err := w.revert(blk.Digest, blk); err != nil {
+			return err
+		}
+
+		if blk = bc.blk.get(blk.Header.PrevBlock); blk == nil {
+			return stores.ErrBlockNotFound
+		}
+	}
+
+	return w.commit()
+}
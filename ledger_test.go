@@ -0,0 +1,573 @@
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func testBlockchainConfPrefix(prefix string) *Config {
+	conf := DefaultConfig()
+
+	conf.BlockStorage = stores.NewBadgerBlockStorage(testDB, []byte(prefix), conf.Hasher)
+	conf.TxStorage = stores.NewBadgerTxStorage(testDB, []byte(prefix))
+	conf.DataKeyIndex = stores.NewBadgerDataKeyIndex(testDB, []byte(prefix))
+	conf.UTXOIndex = stores.NewBadgerUTXOIndex(testDB, []byte(prefix), conf.Hasher)
+	conf.QueryIndex = stores.NewBadgerQueryIndex(testDB, []byte(prefix))
+	conf.Batcher = stores.NewBadgerBatcher(testDB)
+
+	return conf
+}
+
+func testMemBlockchainConf() *Config {
+	conf := DefaultConfig()
+	db := stores.NewMemDB()
+
+	conf.BlockStorage = stores.NewMemBlockStorage(db, nil, conf.Hasher)
+	conf.TxStorage = stores.NewMemTxStorage(db, nil)
+	conf.DataKeyIndex = stores.NewMemDataKeyIndex(db, nil)
+	conf.UTXOIndex = stores.NewMemUTXOIndex(db, nil, conf.Hasher)
+	conf.QueryIndex = stores.NewMemQueryIndex(db, nil)
+	conf.Batcher = stores.NewMemBatcher(db)
+
+	return conf
+}
+
+// testSignedBlock returns a block extending prev signed by all given keypairs
+func testSignedBlock(bc *Blockchain, prev *bcpb.Block, txs []*bcpb.Tx, kps ...*keypair.KeyPair) *bcpb.Block {
+	blk := bcpb.NewBlock()
+	blk.Header.Timestamp = time.Now().UnixNano()
+	if prev != nil {
+		blk.Header.Height = prev.Header.Height + 1
+		blk.Header.PrevBlock = prev.Digest
+		blk.Header.Nonce = prev.Header.Nonce + 1
+	} else {
+		blk.Header.PrevBlock = bcpb.NewZeroDigest(bc.Hasher())
+		blk.Header.Nonce = 1
+	}
+
+	signers := make([]bcpb.PublicKey, len(kps))
+	for i := range kps {
+		signers[i] = kps[i].PublicKey
+	}
+	blk.SetSigners(signers...)
+	blk.Header.N = int32(len(kps))
+	blk.SetTxs(txs, bc.Hasher())
+	blk.SetHash(bc.Hasher())
+
+	for _, kp := range kps {
+		sig, _ := kp.Sign(blk.Digest)
+		blk.Sign(kp.PublicKey, sig)
+	}
+
+	return blk
+}
+
+// testCommit adds the commit signatures of the keypairs and commits the block
+func testCommit(t *testing.T, bc *Blockchain, id bcpb.Digest, kps ...*keypair.KeyPair) {
+	for _, kp := range kps {
+		sig, _ := kp.Sign(bcpb.CommitDigest(bc.Hasher(), id))
+		_, err := bc.AddCommitSignature(id, kp.PublicKey, sig)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, bc.Commit(id))
+}
+
+func testBaseTx(bc *Blockchain, keys ...string) *bcpb.Tx {
+	tx := bcpb.NewBaseTx()
+	for _, k := range keys {
+		tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(k)})
+	}
+	tx.SetDigest(bc.Hasher())
+	return tx
+}
+
+func testUpdateTx(t *testing.T, bc *Blockchain, key string, data string) *bcpb.Tx {
+	txi, err := bc.NewTxInput(bcpb.DataKey(key))
+	assert.Nil(t, err)
+
+	tx := bcpb.NewTx()
+	tx.AddInput(txi)
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key), Data: []byte(data)})
+	tx.SetDigest(bc.Hasher())
+	return tx
+}
+
+func Test_Blockchain_Reorg(t *testing.T) {
+	conf := testBlockchainConfPrefix("reorg/")
+	bc := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "fork:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp1)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// Main chain updates fork:a and creates fork:b
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "fork:a", "main"), testBaseTx(bc, "fork:b")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp1)
+	aid, err := bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(aid))
+
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("fork:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("main"), out.Data)
+
+	// Competing block at the same height with more signatures
+	btxs := []*bcpb.Tx{testBaseTx(bc, "fork:c")}
+	b1 := testSignedBlock(bc, genesis, btxs, kp1, kp2)
+	bid, err := bc.Append(b1, btxs)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(bc.Tips()))
+
+	// Heavier branch wins
+	last, err := bc.ForkChoice()
+	assert.Nil(t, err)
+	assert.Equal(t, bid, last)
+	assert.Equal(t, bid, bc.Last().Digest)
+
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:a"))
+	assert.Nil(t, err)
+	assert.Nil(t, out.Data)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:b"))
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:c"))
+	assert.Nil(t, err)
+
+	// Nothing heavier
+	last, err = bc.ForkChoice()
+	assert.Nil(t, err)
+	assert.Equal(t, bid, last)
+
+	// Explicitly switch back
+	assert.Nil(t, bc.Reorg(aid))
+	assert.Equal(t, aid, bc.Last().Digest)
+
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("main"), out.Data)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:b"))
+	assert.Nil(t, err)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("fork:c"))
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+}
+
+func Test_Blockchain_BranchValidation(t *testing.T) {
+	conf := testBlockchainConfPrefix("reorgval/")
+	bc := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "rv:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp1)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	atxs := []*bcpb.Tx{testBaseTx(bc, "rv:b")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp1)
+	aid, err := bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(aid))
+
+	create := func(nonce, key string) []*bcpb.Tx {
+		tx := bcpb.NewTx()
+		tx.AddInput(bcpb.NewBaseTxInput([]byte(nonce), []byte(key)))
+		tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key)})
+		tx.SetDigest(bc.Hasher())
+		return []*bcpb.Tx{tx}
+	}
+
+	// rv:b only exists on the main chain so the side branch may create it
+	btxs1 := create("1", "rv:b")
+	b1 := testSignedBlock(bc, genesis, btxs1, kp1)
+	_, err = bc.Append(b1, btxs1)
+	assert.Nil(t, err)
+
+	// but not again on top of itself
+	btxs2 := create("2", "rv:b")
+	b2 := testSignedBlock(bc, b1, btxs2, kp1, kp2)
+	_, err = bc.Append(b2, btxs2)
+	assert.Equal(t, errDataKeyExists(bcpb.DataKey("rv:b")), err)
+
+	btxs2 = create("2", "rv:x")
+	b2 = testSignedBlock(bc, b1, btxs2, kp1, kp2)
+	bid, err := bc.Append(b2, btxs2)
+	assert.Nil(t, err)
+
+	assert.Nil(t, bc.Reorg(bid))
+	assert.Equal(t, bid, bc.Last().Digest)
+
+	ref, _, err := bc.tx.dki.Get(bcpb.DataKey("rv:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, btxs1[0].Digest, ref)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("rv:x"))
+	assert.Nil(t, err)
+}
+
+func Test_Blockchain_Rewind(t *testing.T) {
+	conf := testBlockchainConfPrefix("rewind/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "rw:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+	assert.Nil(t, bc.SetLastExec(genesis.Digest))
+
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "rw:a", "1"), testBaseTx(bc, "rw:b")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp)
+	_, err := bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	a2txs := []*bcpb.Tx{testUpdateTx(t, bc, "rw:a", "2")}
+	a2 := testSignedBlock(bc, a1, a2txs, kp)
+	_, err = bc.Append(a2, a2txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a2.Digest))
+	assert.Nil(t, bc.SetLastExec(a2.Digest))
+
+	// Side branch on top of a1 and one on top of genesis
+	ctxs := []*bcpb.Tx{testBaseTx(bc, "rw:c")}
+	c2 := testSignedBlock(bc, a1, ctxs, kp)
+	_, err = bc.Append(c2, ctxs)
+	assert.Nil(t, err)
+	dtxs := []*bcpb.Tx{testBaseTx(bc, "rw:d")}
+	d1 := testSignedBlock(bc, genesis, dtxs, kp)
+	_, err = bc.Append(d1, dtxs)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(bc.Tips()))
+
+	assert.Equal(t, errHeightMismatch, bc.Rewind(3))
+	assert.Nil(t, bc.Rewind(2))
+
+	// Back to height 1
+	assert.Nil(t, bc.Rewind(1))
+	assert.Equal(t, a1.Digest, bc.Last().Digest)
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("rw:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+
+	// The executed block was rolled back
+	eid, _ := bc.blk.st.LastExec()
+	assert.Equal(t, a2.Digest, eid)
+	_, err = NewExecutor(bc, nil).Execute()
+	assert.Equal(t, ErrExecutedBlockReverted, err)
+
+	// Back to genesis.  The rolled back blocks and the branches on them are
+	// kept
+	assert.Nil(t, bc.Rewind(0))
+	assert.Equal(t, genesis.Digest, bc.Last().Digest)
+
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("rw:a"))
+	assert.Nil(t, err)
+	assert.Nil(t, out.Data)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("rw:b"))
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+	assert.True(t, bc.tx.utxo.Exists(gtxs[0].Digest, 0))
+
+	assert.True(t, bc.blk.st.Exists(a1.Digest))
+	assert.True(t, bc.blk.st.Exists(c2.Digest))
+	assert.Equal(t, 3, len(bc.Tips()))
+	_, err = bc.tx.Get(atxs[0].Digest)
+	assert.Nil(t, err)
+
+	// The rolled back blocks can be switched back to
+	assert.Nil(t, bc.Reorg(a2.Digest))
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("rw:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), out.Data)
+	assert.Nil(t, bc.Rewind(0))
+
+	// The ledger can be extended again
+	etxs := []*bcpb.Tx{testUpdateTx(t, bc, "rw:a", "e")}
+	e1 := testSignedBlock(bc, genesis, etxs, kp)
+	_, err = bc.Append(e1, etxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(e1.Digest))
+}
+
+func Test_Blockchain_DeleteDataKey(t *testing.T) {
+	conf := testBlockchainConfPrefix("delete/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "del:a", "del:b")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// Creating an existing key is rejected
+	recreate := bcpb.NewBaseTx()
+	recreate.Inputs[0].AddArgs(nil, []byte("del:a"))
+	recreate.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("del:a"), Data: []byte("again")})
+	recreate.SetDigest(bc.Hasher())
+	assert.NotNil(t, bc.ValidateTx(recreate))
+
+	// Spend del:a without emitting it again
+	txi, err := bc.NewTxInput(bcpb.DataKey("del:a"))
+	assert.Nil(t, err)
+	dtx := bcpb.NewTx()
+	dtx.AddInput(txi)
+	dtx.SetDigest(bc.Hasher())
+
+	atxs := []*bcpb.Tx{dtx, testUpdateTx(t, bc, "del:b", "1")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp)
+	_, err = bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("del:a"))
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+	_, err = bc.NewTxInput(bcpb.DataKey("del:a"))
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("del:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+
+	var c int
+	bc.tx.dki.Iter(bcpb.DataKey("del:"), func(dk bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		c++
+		return true
+	})
+	assert.Equal(t, 1, c)
+
+	// A deleted key can be created again
+	assert.Nil(t, bc.ValidateTx(recreate))
+	a2txs := []*bcpb.Tx{recreate}
+	a2 := testSignedBlock(bc, a1, a2txs, kp)
+	_, err = bc.Append(a2, a2txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a2.Digest))
+
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("del:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("again"), out.Data)
+
+	// Rewinding restores the tombstone then the original output
+	assert.Nil(t, bc.Rewind(1))
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("del:a"))
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+
+	assert.Nil(t, bc.Rewind(0))
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("del:a"))
+	assert.Nil(t, err)
+	assert.Nil(t, out.Data)
+}
+
+func Test_Blockchain_UTXO(t *testing.T) {
+	conf := testBlockchainConfPrefix("utxo/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	// Single tx with multiple outputs
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "utxo:a", "utxo:b")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+	assert.True(t, bc.tx.utxo.Exists(gtxs[0].Digest, 0))
+	assert.True(t, bc.tx.utxo.Exists(gtxs[0].Digest, 1))
+
+	// Spending the same output twice in a block is rejected
+	dtxs := []*bcpb.Tx{testUpdateTx(t, bc, "utxo:a", "1"), testUpdateTx(t, bc, "utxo:a", "2")}
+	d1 := testSignedBlock(bc, genesis, dtxs, kp)
+	_, err := bc.Append(d1, dtxs)
+	assert.Equal(t, errTxSpent, err)
+
+	stale := testUpdateTx(t, bc, "utxo:a", "stale")
+
+	atxs := []*bcpb.Tx{dtxs[0]}
+	a1 := testSignedBlock(bc, genesis, atxs, kp)
+	_, err = bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	assert.False(t, bc.tx.utxo.Exists(gtxs[0].Digest, 0))
+	assert.True(t, bc.tx.utxo.Exists(atxs[0].Digest, 0))
+	assert.Equal(t, errTxSpent, bc.ValidateTx(stale))
+
+	// The other output of the same tx is still spendable
+	assert.Nil(t, bc.ValidateTx(testUpdateTx(t, bc, "utxo:b", "1")))
+
+	// Rewinding makes the output unspent again
+	assert.Nil(t, bc.Rewind(0))
+	assert.True(t, bc.tx.utxo.Exists(gtxs[0].Digest, 0))
+	assert.False(t, bc.tx.utxo.Exists(atxs[0].Digest, 0))
+	assert.Nil(t, bc.ValidateTx(stale))
+
+	// An index missing outputs of the main chain is rebuilt from it
+	assert.Nil(t, bc.Reorg(a1.Digest))
+	assert.Nil(t, bc.tx.utxo.Remove(nil, gtxs[0].Digest, 1, gtxs[0].Outputs[1]))
+	assert.Nil(t, bc.tx.utxo.Remove(nil, atxs[0].Digest, 0, atxs[0].Outputs[0]))
+	assert.Nil(t, bc.tx.utxo.Add(nil, gtxs[0].Digest, 0, gtxs[0].Outputs[0]))
+	assert.Equal(t, errTxSpent, bc.ValidateTx(testUpdateTx(t, bc, "utxo:b", "2")))
+
+	assert.Nil(t, bc.ReindexUTXOs())
+	assert.False(t, bc.tx.utxo.Exists(gtxs[0].Digest, 0))
+	assert.True(t, bc.tx.utxo.Exists(gtxs[0].Digest, 1))
+	assert.True(t, bc.tx.utxo.Exists(atxs[0].Digest, 0))
+	assert.Nil(t, bc.ValidateTx(testUpdateTx(t, bc, "utxo:b", "2")))
+	assert.Equal(t, errTxSpent, bc.ValidateTx(stale))
+}
+
+func Test_Blockchain_UncommittedDoubleSpend(t *testing.T) {
+	conf := testBlockchainConfPrefix("dspend/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "dspend:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// b1 and b2 both spend the genesis output.  b2 extends the uncommitted b1
+	txs1 := []*bcpb.Tx{testUpdateTx(t, bc, "dspend:a", "1")}
+	txs2 := []*bcpb.Tx{testUpdateTx(t, bc, "dspend:a", "2")}
+
+	b1 := testSignedBlock(bc, genesis, txs1, kp)
+	_, err := bc.Append(b1, txs1)
+	assert.Nil(t, err)
+
+	b2 := testSignedBlock(bc, b1, txs2, kp)
+	_, err = bc.Append(b2, txs2)
+	assert.Equal(t, errTxSpent, err)
+
+	// Outputs of an uncommitted ancestor can be spent
+	tx := bcpb.NewTx()
+	tx.AddInput(bcpb.NewTxInput(txs1[0].Digest, 0, nil))
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("dspend:a"), Data: []byte("3")})
+	tx.SetDigest(bc.Hasher())
+	txs3 := []*bcpb.Tx{tx}
+
+	b3 := testSignedBlock(bc, b1, txs3, kp)
+	_, err = bc.Append(b3, txs3)
+	assert.Nil(t, err)
+
+	assert.Nil(t, bc.Commit(b1.Digest))
+	assert.Nil(t, bc.Commit(b3.Digest))
+
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("dspend:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("3"), out.Data)
+}
+
+func Test_Blockchain_MemStores(t *testing.T) {
+	conf := testMemBlockchainConf()
+	bc := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "mem:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp1)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "mem:a", "main")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp1)
+	aid, err := bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(aid))
+
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("mem:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("main"), out.Data)
+
+	// Heavier competing branch
+	btxs := []*bcpb.Tx{testBaseTx(bc, "mem:b")}
+	b1 := testSignedBlock(bc, genesis, btxs, kp1, kp2)
+	bid, err := bc.Append(b1, btxs)
+	assert.Nil(t, err)
+
+	last, err := bc.ForkChoice()
+	assert.Nil(t, err)
+	assert.Equal(t, bid, last)
+
+	out, err = bc.GetTXOByDataKey(bcpb.DataKey("mem:a"))
+	assert.Nil(t, err)
+	assert.Nil(t, out.Data)
+
+	// Rewind to genesis keeps the branches above it
+	assert.Nil(t, bc.Rewind(0))
+	assert.Equal(t, genesis.Digest, bc.Last().Digest)
+	_, err = bc.GetTXOByDataKey(bcpb.DataKey("mem:b"))
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+	assert.Equal(t, 2, len(bc.Tips()))
+}
+
+func Test_Blockchain_Heights(t *testing.T) {
+	conf := testBlockchainConfPrefix("heights/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "height:0")}
+	blk := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(blk, gtxs))
+	assert.Nil(t, bc.Commit(blk.Digest))
+
+	ids := []bcpb.Digest{blk.Digest}
+	for i := 1; i < 4; i++ {
+		txs := []*bcpb.Tx{testBaseTx(bc, fmt.Sprintf("height:%d", i))}
+		blk = testSignedBlock(bc, blk, txs, kp)
+		_, err := bc.Append(blk, txs)
+		assert.Nil(t, err)
+		assert.Nil(t, bc.Commit(blk.Digest))
+		ids = append(ids, blk.Digest)
+	}
+
+	got, err := bc.GetByHeight(2)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[2], got.Digest)
+
+	digests := func(blocks []*bcpb.Block) []bcpb.Digest {
+		out := make([]bcpb.Digest, len(blocks))
+		for i, b := range blocks {
+			out[i] = b.Digest
+		}
+		return out
+	}
+
+	blocks, err := bc.BlocksByHeight(1, 2, false)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[1:3], digests(blocks))
+
+	blocks, err = bc.BlocksByHeight(3, 0, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []bcpb.Digest{ids[3], ids[2], ids[1], ids[0]}, digests(blocks))
+
+	// Rolled back heights are removed
+	assert.Nil(t, bc.Rewind(1))
+	_, err = bc.GetByHeight(2)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	blocks, err = bc.BlocksByHeight(0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[:2], digests(blocks))
+
+	// Heights missing from the index are rebuilt from the last block
+	assert.Nil(t, conf.BlockStorage.RemoveHeight(nil, 0))
+	assert.Nil(t, conf.BlockStorage.RemoveHeight(nil, 1))
+	blocks, err = bc.BlocksByHeight(0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(blocks))
+
+	assert.Nil(t, bc.ReindexHeights())
+	blocks, err = bc.BlocksByHeight(0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[:2], digests(blocks))
+}
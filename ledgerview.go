@@ -0,0 +1,187 @@
+package blockchain
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// ledgerView is the unspent outputs and DataKey states of a branch.  It
+// overlays the changes made by blocks not yet reflected in the UTXOIndex and
+// DataKeyIndex onto them
+type ledgerView struct {
+	tx *txStore
+	// Unspent state of the changed outputs by OutPoint
+	changes map[string]bool
+	// States of the changed DataKeys.  A nil Ref is a DataKey that does not
+	// exist
+	keys map[string]stores.UndoEntry
+}
+
+func newLedgerView(tx *txStore) *ledgerView {
+	return &ledgerView{
+		tx:      tx,
+		changes: make(map[string]bool),
+		keys:    make(map[string]stores.UndoEntry),
+	}
+}
+
+// unspent returns true if the output is unspent
+func (v *ledgerView) unspent(ref bcpb.Digest, idx int32) bool {
+	if ok, changed := v.changes[string(stores.OutPoint(ref, idx))]; changed {
+		return ok
+	}
+	return v.tx.utxo.Exists(ref, idx)
+}
+
+func (v *ledgerView) set(ref bcpb.Digest, idx int32, unspent bool) {
+	v.changes[string(stores.OutPoint(ref, idx))] = unspent
+}
+
+// dataKey returns the state of the DataKey.  The returned Ref is nil if the
+// DataKey does not exist
+func (v *ledgerView) dataKey(key bcpb.DataKey) (stores.UndoEntry, error) {
+	if e, ok := v.keys[string(key)]; ok {
+		return e, nil
+	}
+
+	ref, i, err := v.tx.dki.Get(key)
+	switch err {
+	case nil, stores.ErrDataKeyDeleted:
+		return stores.UndoEntry{Key: key, Ref: ref, Index: i}, nil
+	case stores.ErrDataKeyNotFound:
+		return stores.UndoEntry{Key: key}, nil
+	}
+
+	return stores.UndoEntry{}, err
+}
+
+// apply marks the outputs spent by the tx as spent and its outputs as unspent.
+// The DataKeys of its outputs are set to them and those deleted by the tx are
+// marked deleted, see ledgerWriter.deletedDataKeys
+func (v *ledgerView) apply(tx *bcpb.Tx) error {
+	for _, in := range tx.Inputs {
+		if in.IsBase() {
+			continue
+		}
+		v.set(in.Ref, in.Index, false)
+
+		ref, err := v.tx.Get(in.Ref)
+		if err != nil {
+			return err
+		}
+		if in.Index < 0 || int(in.Index) >= len(ref.Outputs) {
+			return errInvalidOutputIndex
+		}
+
+		key := ref.Outputs[in.Index].DataKey
+		if hasDataKey(tx.Outputs, key) {
+			continue
+		}
+		cur, err := v.dataKey(key)
+		if err != nil {
+			return err
+		}
+		if !cur.Deleted() && cur.Ref.Equal(in.Ref) && cur.Index == in.Index {
+			v.keys[string(key)] = stores.UndoEntry{Key: key, Ref: tx.Digest, Index: stores.TombstoneIndex}
+		}
+	}
+
+	for i, txo := range tx.Outputs {
+		v.set(tx.Digest, int32(i), true)
+		v.keys[string(txo.DataKey)] = stores.UndoEntry{Key: txo.DataKey, Ref: tx.Digest, Index: int32(i)}
+	}
+
+	return nil
+}
+
+// revert reverses applying the txs of the committed block.  The DataKeys are
+// restored using the undo log of the block
+func (v *ledgerView) revert(id bcpb.Digest, txs []*bcpb.Tx) error {
+	for i := len(txs) - 1; i >= 0; i-- {
+		for j := range txs[i].Outputs {
+			v.set(txs[i].Digest, int32(j), false)
+		}
+		for _, in := range txs[i].Inputs {
+			if !in.IsBase() {
+				v.set(in.Ref, in.Index, true)
+			}
+		}
+	}
+
+	undo, err := v.tx.dki.Undo(id)
+	if err != nil {
+		return err
+	}
+	for _, e := range undo {
+		v.keys[string(e.Key)] = e
+	}
+
+	return nil
+}
+
+// checkUnspent makes sure none of the inputs of the txs have been spent or are
+// spent by another input of the txs
+func (v *ledgerView) checkUnspent(txs []*bcpb.Tx) error {
+	spent := make(map[string]struct{})
+
+	for _, tx := range txs {
+		for _, in := range tx.Inputs {
+			if in.IsBase() {
+				continue
+			}
+
+			if !v.unspent(in.Ref, in.Index) {
+				return errTxSpent
+			}
+
+			k := string(stores.OutPoint(in.Ref, in.Index))
+			if _, ok := spent[k]; ok {
+				return errTxSpent
+			}
+			spent[k] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// branchView returns the state of the branch ending at the given block.  The
+// main chain blocks above the common ancestor of the branch are reverted and
+// the blocks of the branch above it applied.  ErrPrevBlockMismatch is returned
+// if the block does not exist
+func (bc *Blockchain) branchView(id bcpb.Digest) (*ledgerView, error) {
+	if !bc.blk.st.Exists(id) {
+		return nil, ErrPrevBlockMismatch
+	}
+	view := newLedgerView(bc.tx)
+
+	lid, _ := bc.blk.st.Last()
+	oldBranch, newBranch, err := bc.blk.branches(lid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blk := range oldBranch {
+		txs, err := bc.getBlockTxs(blk)
+		if err != nil {
+			return nil, err
+		}
+		if err = view.revert(blk.Digest, txs); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := len(newBranch) - 1; i >= 0; i-- {
+		txs, err := bc.getBlockTxs(newBranch[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			if err = view.apply(tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return view, nil
+}
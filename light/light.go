@@ -0,0 +1,242 @@
+// Package light implements a header only client.  It verifies the chain of
+// block headers and their signatures without any transaction storage, and
+// checks tx inclusion using merkle proofs against the verified headers.
+package light
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+	"github.com/hexablock/hasher"
+)
+
+var (
+	// ErrPrevBlockMismatch is returned when a header does not extend the last
+	// verified header
+	ErrPrevBlockMismatch = errors.New("previous block mismatch")
+	// ErrTxNotInBlock is returned when a tx inclusion proof does not verify
+	// against the header root
+	ErrTxNotInBlock = errors.New("tx not in block")
+
+	errHeightMismatch = errors.New("height mismatch")
+	errInvalidNonce   = errors.New("invalid nonce")
+)
+
+// HeaderStorage stores the verified headers and their signatures.  Headers are
+// stored as blocks without txs so stores.BadgerBlockStorage can be used
+type HeaderStorage interface {
+	// Get a header by its digest id
+	Get(bcpb.Digest) (*bcpb.Block, error)
+	// Returns the genesis header
+	Genesis() (bcpb.Digest, *bcpb.Block)
+	// Last verified header
+	Last() (bcpb.Digest, *bcpb.Block)
+	// Sets the genesis header digest
+	SetGenesis(stores.Batch, bcpb.Digest) error
+	// Sets the last verified header digest
+	SetLast(stores.Batch, bcpb.Digest) error
+	// Adds a header returning an error if it already exists
+	Add(stores.Batch, *bcpb.Block) (bcpb.Digest, error)
+}
+
+// Batcher creates write batches for the HeaderStorage
+type Batcher interface {
+	NewBatch() stores.Batch
+}
+
+// HeaderValidator is called to validate a header before its signatures are
+// verified
+type HeaderValidator func(*bcpb.BlockHeader) error
+
+// Config holds the light client config
+type Config struct {
+	// Hash function to use
+	Hasher hasher.Hasher
+
+	// Elliptic curve for verification
+	Curve elliptic.Curve
+
+	// Header store.  This is required
+	Store HeaderStorage
+
+	// Batcher creates write batches for the header store so a header and the
+	// last header pointer are written atomically.  If nil writes are
+	// immediate
+	Batcher Batcher
+}
+
+// DefaultConfig returns a config with the default hasher and elliptic curve
+func DefaultConfig() *Config {
+	return &Config{
+		Hasher: hasher.Default(),
+		Curve:  elliptic.P256(),
+	}
+}
+
+// Client verifies and tracks the header chain.  Headers are verified the same
+// way as full blocks are by the blockchain: linkage via PrevBlock, height,
+// nonce and the S of N signatures.
+type Client struct {
+	h     hasher.Hasher
+	curve elliptic.Curve
+	// Header validation function
+	hv HeaderValidator
+
+	st      HeaderStorage
+	batcher Batcher
+}
+
+// New instantiates a new light client.  By default header validation is
+// disabled
+func New(conf *Config) *Client {
+	return &Client{
+		h:       conf.Hasher,
+		curve:   conf.Curve,
+		hv:      func(*bcpb.BlockHeader) error { return nil },
+		st:      conf.Store,
+		batcher: conf.Batcher,
+	}
+}
+
+// SetHeaderValidator sets the header validator function
+func (c *Client) SetHeaderValidator(hv HeaderValidator) {
+	c.hv = hv
+}
+
+// Genesis returns the genesis header or nil if it has not been set
+func (c *Client) Genesis() *bcpb.BlockHeader {
+	_, blk := c.st.Genesis()
+	if blk == nil {
+		return nil
+	}
+	return blk.Header
+}
+
+// Last returns the digest and last verified header
+func (c *Client) Last() (bcpb.Digest, *bcpb.BlockHeader) {
+	id, blk := c.st.Last()
+	if blk == nil {
+		return nil, nil
+	}
+	return id, blk.Header
+}
+
+// Header returns a verified header by its digest
+func (c *Client) Header(id bcpb.Digest) (*bcpb.BlockHeader, error) {
+	blk, err := c.st.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return blk.Header, nil
+}
+
+// SetGenesis verifies and sets the genesis header.  This can only be called
+// once
+func (c *Client) SetGenesis(header *bcpb.BlockHeader, signatures [][]byte) (bcpb.Digest, error) {
+	if _, gen := c.st.Genesis(); gen != nil {
+		return nil, fmt.Errorf("genesis block already set")
+	}
+
+	blk, err := c.verify(header, signatures)
+	if err != nil {
+		return nil, err
+	}
+
+	var id bcpb.Digest
+	err = c.write(func(batch stores.Batch) error {
+		if id, err = c.st.Add(batch, blk); err != nil {
+			return err
+		}
+		if err = c.st.SetGenesis(batch, id); err != nil {
+			return err
+		}
+		return c.st.SetLast(batch, id)
+	})
+
+	return id, err
+}
+
+// Append verifies the header extends the last verified header and is signed by
+// at least S of its signers.  On success it becomes the last header
+func (c *Client) Append(header *bcpb.BlockHeader, signatures [][]byte) (bcpb.Digest, error) {
+	lid, last := c.st.Last()
+	if last == nil {
+		return nil, ErrPrevBlockMismatch
+	}
+
+	if !header.PrevBlock.Equal(lid) {
+		return nil, ErrPrevBlockMismatch
+	}
+
+	if header.Height != last.Header.Height+1 {
+		return nil, errHeightMismatch
+	} else if header.Nonce < last.Header.Nonce {
+		return nil, errInvalidNonce
+	}
+
+	blk, err := c.verify(header, signatures)
+	if err != nil {
+		return nil, err
+	}
+
+	var id bcpb.Digest
+	err = c.write(func(batch stores.Batch) error {
+		if id, err = c.st.Add(batch, blk); err != nil {
+			return err
+		}
+		return c.st.SetLast(batch, id)
+	})
+
+	return id, err
+}
+
+// VerifyTx verifies the tx is included in the verified block using the merkle
+// proof against the block header root
+func (c *Client) VerifyTx(block bcpb.Digest, tx bcpb.Digest, proof *bcpb.MerkleProof) error {
+	header, err := c.Header(block)
+	if err != nil {
+		return err
+	}
+
+	if !bcpb.VerifyProof(header.Root, tx, proof) {
+		return ErrTxNotInBlock
+	}
+
+	return nil
+}
+
+// write calls f with a new batch committing it if f succeeds.  Without a
+// batcher f is called with a nil batch
+func (c *Client) write(f func(stores.Batch) error) error {
+	if c.batcher == nil {
+		return f(nil)
+	}
+
+	batch := c.batcher.NewBatch()
+	defer batch.Discard()
+
+	if err := f(batch); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// verify validates the header and its signatures returning a header only block
+// to be stored
+func (c *Client) verify(header *bcpb.BlockHeader, signatures [][]byte) (*bcpb.Block, error) {
+	if err := c.hv(header); err != nil {
+		return nil, err
+	}
+
+	sh := header.Hash(c.h)
+	sc := keypair.CountSignatures(c.curve, c.h, sh, header.Signers, signatures)
+	if sc < header.S {
+		return nil, bcpb.ErrSignatureVerificationFailed
+	}
+
+	return &bcpb.Block{Header: header, Signatures: signatures, Digest: sh}, nil
+}
@@ -0,0 +1,126 @@
+package light
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func testBadgerDB(tmpdir string) (*badger.DB, error) {
+	opt := badger.DefaultOptions
+	opt.Dir = tmpdir
+	opt.ValueDir = tmpdir
+	return badger.Open(opt)
+}
+
+func testHeader(conf *Config, prev bcpb.Digest, height uint32, nonce uint64, txs bcpb.Digests,
+	s int32, kps ...*keypair.KeyPair) (*bcpb.BlockHeader, [][]byte) {
+
+	blk := bcpb.NewBlock()
+	blk.Header.Height = height
+	blk.Header.PrevBlock = prev
+	blk.Header.Nonce = nonce
+	blk.Header.S = s
+	for _, kp := range kps {
+		blk.AddSigner(kp.PublicKey)
+	}
+	blk.Header.N = int32(len(kps))
+	blk.Txs = txs
+	blk.SetHash(conf.Hasher)
+
+	for _, kp := range kps {
+		sig, _ := kp.Sign(blk.Digest)
+		blk.Sign(kp.PublicKey, sig)
+	}
+
+	return blk.Header, blk.Signatures
+}
+
+func testTxDigests(conf *Config, n int) bcpb.Digests {
+	txs := make(bcpb.Digests, n)
+	for i := range txs {
+		tx := bcpb.NewBaseTx()
+		tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey([]byte{'k', byte(i)})})
+		tx.SetDigest(conf.Hasher)
+		txs[i] = tx.Digest
+	}
+	return txs
+}
+
+func Test_Client(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "light-")
+	defer os.RemoveAll(tmpdir)
+
+	db, err := testBadgerDB(tmpdir)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	conf := DefaultConfig()
+	conf.Store = stores.NewBadgerBlockStorage(db, []byte("light/"), conf.Hasher)
+	conf.Batcher = stores.NewBadgerBatcher(db)
+	client := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	assert.Nil(t, client.Genesis())
+
+	// Genesis
+	gh, gsigs := testHeader(conf, bcpb.NewZeroDigest(conf.Hasher), 0, 1, testTxDigests(conf, 1), 1, kp1)
+	gid, err := client.SetGenesis(gh, gsigs)
+	assert.Nil(t, err)
+	_, err = client.SetGenesis(gh, gsigs)
+	assert.NotNil(t, err)
+
+	lid, last := client.Last()
+	assert.Equal(t, gid, lid)
+	assert.Equal(t, gh.Root, last.Root)
+
+	// Requires 2 of 2 but only 1 signed
+	txs := testTxDigests(conf, 5)
+	h1, sigs := testHeader(conf, gid, 1, 2, txs, 2, kp1, kp2)
+	partial := [][]byte{sigs[0], nil}
+	_, err = client.Append(h1, partial)
+	assert.Equal(t, bcpb.ErrSignatureVerificationFailed, err)
+
+	// Signature by the wrong signer index
+	_, err = client.Append(h1, [][]byte{sigs[1], sigs[0]})
+	assert.Equal(t, bcpb.ErrSignatureVerificationFailed, err)
+
+	// Linkage
+	bad, bsigs := testHeader(conf, bcpb.NewZeroDigest(conf.Hasher), 1, 2, txs, 1, kp1)
+	_, err = client.Append(bad, bsigs)
+	assert.Equal(t, ErrPrevBlockMismatch, err)
+	bad, bsigs = testHeader(conf, gid, 2, 2, txs, 1, kp1)
+	_, err = client.Append(bad, bsigs)
+	assert.Equal(t, errHeightMismatch, err)
+	bad, bsigs = testHeader(conf, gid, 1, 0, txs, 1, kp1)
+	_, err = client.Append(bad, bsigs)
+	assert.Equal(t, errInvalidNonce, err)
+
+	id1, err := client.Append(h1, sigs)
+	assert.Nil(t, err)
+	lid, _ = client.Last()
+	assert.Equal(t, id1, lid)
+
+	// Tx inclusion
+	proof, err := txs.Proof(txs[3])
+	assert.Nil(t, err)
+	assert.Nil(t, client.VerifyTx(id1, txs[3], proof))
+	assert.Equal(t, ErrTxNotInBlock, client.VerifyTx(id1, txs[2], proof))
+	assert.Equal(t, ErrTxNotInBlock, client.VerifyTx(gid, txs[3], proof))
+	assert.NotNil(t, client.VerifyTx(bcpb.NewZeroDigest(conf.Hasher), txs[3], proof))
+
+	// Only headers and signatures are stored
+	blk, err := conf.Store.Get(id1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(blk.Txs))
+	assert.Equal(t, 2, len(blk.Signatures))
+}
@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/script"
+)
+
+// ErrLogicTagRegistered is returned when registering an evaluator for a tag
+// that already has one
+var ErrLogicTagRegistered = errors.New("logic tag already registered")
+
+// ChainContext is the chain state an input is validated against.  It is that
+// of the block containing the tx being validated.  Txs validated outside of a
+// block are given the height following the last committed block and the
+// timestamp of the last committed block as the block they will be included in
+// is not yet known
+type ChainContext struct {
+	// Height of the block the spending tx is included in
+	Height uint32
+	// Timestamp of that block in unix nanoseconds
+	Time int64
+	// Digest of the block preceding it
+	Last bcpb.Digest
+}
+
+// LogicEvaluator evaluates the unlock condition of an output when it is spent.
+// TxOutput.Logic is laid out as the required signatures byte, the tag
+// selecting the evaluator and the evaluator specific data i.e. Logic[2:].  It
+// is called with the referenced output, the input spending it, the public keys
+// that validly signed the input and the chain context.  A non-nil error rejects
+// the input.
+type LogicEvaluator func(ref *bcpb.TxOutput, in *bcpb.TxInput, signers []bcpb.PublicKey, ctx *ChainContext) error
+
+// RegisterLogic registers the evaluator for outputs with the given logic tag.
+// The script tag is registered by default
+func (bc *Blockchain) RegisterLogic(tag byte, ev LogicEvaluator) error {
+	bc.logicMu.Lock()
+	defer bc.logicMu.Unlock()
+
+	if _, ok := bc.logic[tag]; ok {
+		return ErrLogicTagRegistered
+	}
+
+	bc.logic[tag] = ev
+	return nil
+}
+
+// evaluateLogic runs the evaluator selected by the output's logic tag.  Logic
+// with only the required signatures byte has nothing to evaluate.  As before
+// evaluators existed, logic with a tag that has no registered evaluator is not
+// evaluated and only the required signatures are checked
+func (bc *Blockchain) evaluateLogic(ref *bcpb.TxOutput, in *bcpb.TxInput, signers []bcpb.PublicKey, ctx *ChainContext) error {
+	if len(ref.Logic) < 2 {
+		return nil
+	}
+
+	bc.logicMu.RLock()
+	ev, ok := bc.logic[ref.Logic[1]]
+	bc.logicMu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	return ev(ref, in, signers, ctx)
+}
+
+// blockContext returns the context of the txs in the given block
+func blockContext(blk *bcpb.Block) *ChainContext {
+	return &ChainContext{
+		Height: blk.Header.Height,
+		Time:   blk.Header.Timestamp,
+		Last:   blk.Header.PrevBlock,
+	}
+}
+
+// chainContext returns the context of txs not in a block i.e. the height
+// following the last committed block along with its timestamp and digest
+func (bc *Blockchain) chainContext() *ChainContext {
+	ctx := &ChainContext{}
+
+	if lid, last := bc.blk.st.Last(); last != nil {
+		ctx.Height = last.Header.Height + 1
+		ctx.Time = last.Header.Timestamp
+		ctx.Last = lid
+	}
+
+	return ctx
+}
+
+// evaluateScript is the LogicEvaluator for script.Tag
+func (bc *Blockchain) evaluateScript(ref *bcpb.TxOutput, in *bcpb.TxInput, signers []bcpb.PublicKey, ctx *ChainContext) error {
+	s, _ := script.ParseLogic(ref.Logic)
+
+	return script.Execute(s, &script.Context{
+		Args:    in.Args(),
+		Signers: signers,
+		Height:  ctx.Height,
+		Time:    ctx.Time,
+		Hasher:  bc.h,
+	})
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/script"
+)
+
+func Test_Blockchain_RegisterLogic(t *testing.T) {
+	conf := testBlockchainConfPrefix("logic/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	errDenied := errors.New("denied")
+
+	var (
+		called  int
+		gotCtx  *ChainContext
+		signers []bcpb.PublicKey
+	)
+
+	// Requires the data following the tag as the first argument
+	ev := func(ref *bcpb.TxOutput, in *bcpb.TxInput, s []bcpb.PublicKey, ctx *ChainContext) error {
+		called++
+		gotCtx, signers = ctx, s
+		if args := in.Args(); len(args) > 0 && bytes.Equal(args[0], ref.Logic[2:]) {
+			return nil
+		}
+		return errDenied
+	}
+
+	assert.Equal(t, ErrLogicTagRegistered, bc.RegisterLogic(script.Tag, ev))
+	assert.Nil(t, bc.RegisterLogic(0x10, ev))
+	assert.Equal(t, ErrLogicTagRegistered, bc.RegisterLogic(0x10, ev))
+
+	gtx := bcpb.NewBaseTx()
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("vault:a"), Logic: []byte{0, 0x10, 'o', 'k'}})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("vault:b"), Logic: []byte{0, 0x20}})
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	spend := func(key string, arg []byte) error {
+		txi, _ := bc.NewTxInput(bcpb.DataKey(key))
+		txi.AddPubKey(kp.PublicKey)
+		txi.AddArgs(arg)
+		sig, _ := kp.Sign(txi.Hash(bc.Hasher()))
+		txi.Sign(kp.PublicKey, sig)
+
+		tx := bcpb.NewTx()
+		tx.AddInput(txi)
+		tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key)})
+		tx.SetDigest(bc.Hasher())
+		return bc.ValidateTx(tx)
+	}
+
+	assert.Equal(t, errDenied, spend("vault:a", []byte("no")))
+	assert.Nil(t, spend("vault:a", []byte("ok")))
+	assert.Equal(t, 2, called)
+	assert.Equal(t, uint32(1), gotCtx.Height)
+	assert.Equal(t, genesis.Digest, gotCtx.Last)
+	assert.Equal(t, genesis.Header.Timestamp, gotCtx.Time)
+	assert.Equal(t, []bcpb.PublicKey{kp.PublicKey}, signers)
+
+	// Unregistered tags are not evaluated
+	assert.Nil(t, spend("vault:b", nil))
+	assert.Equal(t, 2, called)
+}
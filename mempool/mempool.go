@@ -0,0 +1,322 @@
+// Package mempool holds validated txs between submission and their inclusion
+// in a block
+package mempool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/hexablock/blockchain"
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+var (
+	// ErrTxExists is returned when the tx is already in the pool
+	ErrTxExists = errors.New("tx exists")
+	// ErrConflict is returned when a tx spends an output or writes a DataKey
+	// used by a pending tx of equal or higher priority
+	ErrConflict = errors.New("tx conflicts with pending tx")
+	// ErrPoolFull is returned when the pool is at its limits and the tx does
+	// not have a higher priority than any pending tx
+	ErrPoolFull = errors.New("mempool full")
+	// ErrTxTooLarge is returned when a single tx exceeds the byte limit
+	ErrTxTooLarge = errors.New("tx too large")
+)
+
+// commitBuffer is the number of commit events buffered for a mempool
+// subscribed to its ledger
+const commitBuffer = 128
+
+// Ledger validates txs against the current ledger state
+type Ledger interface {
+	ValidateTx(*bcpb.Tx) error
+}
+
+// Subscriber is implemented by ledgers publishing events e.g.
+// *blockchain.Blockchain.  A Mempool on such a ledger updates itself as blocks
+// are committed
+type Subscriber interface {
+	Subscribe(blockchain.EventFilter, int) *blockchain.Subscription
+}
+
+// Config holds the mempool limits
+type Config struct {
+	// Max number of pending txs
+	MaxTxs int
+	// Max total size in bytes of all pending txs
+	MaxBytes int
+}
+
+// DefaultConfig returns a config with sane default limits
+func DefaultConfig() *Config {
+	return &Config{
+		MaxTxs:   5000,
+		MaxBytes: 32 << 20,
+	}
+}
+
+type entry struct {
+	tx       *bcpb.Tx
+	priority uint64
+	// Arrival order
+	seq  uint64
+	size int
+	// Spent outputs and written DataKeys
+	claims []string
+}
+
+// before returns true if the entry should be included in a block before the
+// other
+func (e *entry) before(o *entry) bool {
+	if e.priority == o.priority {
+		return e.seq < o.seq
+	}
+	return e.priority > o.priority
+}
+
+// Mempool holds pending txs ordered by priority and then arrival.  Txs are
+// validated against the committed ledger state on submission and not against
+// other pending txs, so a tx spending an output of a pending tx is rejected
+// until that tx has been committed.  No two pending txs may spend the same
+// output or write the same DataKey.
+type Mempool struct {
+	conf   *Config
+	ledger Ledger
+	// Commit events of the ledger if it is a Subscriber
+	sub *blockchain.Subscription
+
+	mu  sync.Mutex
+	seq uint64
+	// Total size of all txs
+	size int
+	// Pending txs by digest
+	txs map[string]*entry
+	// Spent output or DataKey claim to the tx holding it
+	claims map[string]*entry
+}
+
+// New returns a new Mempool validating txs against the given ledger.  If the
+// ledger is a Subscriber the mempool subscribes to its commit events and calls
+// Update for each committed block until it is closed
+func New(conf *Config, ledger Ledger) *Mempool {
+	mp := &Mempool{
+		conf:   conf,
+		ledger: ledger,
+		txs:    make(map[string]*entry),
+		claims: make(map[string]*entry),
+	}
+
+	if s, ok := ledger.(Subscriber); ok {
+		filter := blockchain.EventFilter{Types: blockchain.EventBlockCommitted}
+		mp.sub = s.Subscribe(filter, commitBuffer)
+		go mp.Watch(mp.sub.Events())
+	}
+
+	return mp
+}
+
+// Watch calls Update for each committed block event received on the channel
+// until it is closed.  It is only needed for ledgers that are not a Subscriber
+func (mp *Mempool) Watch(events <-chan blockchain.Event) {
+	for ev := range events {
+		if ev.Type == blockchain.EventBlockCommitted {
+			mp.Update(ev.Block)
+		}
+	}
+}
+
+// Close stops the mempool from following the commit events of its ledger
+func (mp *Mempool) Close() {
+	if mp.sub != nil {
+		mp.sub.Close()
+	}
+}
+
+// Add validates and adds the tx with the given priority.  If the tx conflicts
+// with pending txs it replaces them only if its priority is higher than that of
+// all of them.  If the pool is full the lowest priority txs are evicted to
+// make room provided they have a lower priority than the tx.
+func (mp *Mempool) Add(tx *bcpb.Tx, priority uint64) error {
+	if err := mp.ledger.ValidateTx(tx); err != nil {
+		return err
+	}
+
+	e := &entry{
+		tx:       tx,
+		priority: priority,
+		size:     tx.Size(),
+		claims:   blockchain.TxClaims(tx),
+	}
+
+	if mp.conf.MaxBytes > 0 && e.size > mp.conf.MaxBytes {
+		return ErrTxTooLarge
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if _, ok := mp.txs[tx.Digest.String()]; ok {
+		return ErrTxExists
+	}
+
+	// Collect conflicting txs
+	conflicts := make(map[string]*entry)
+	for _, c := range e.claims {
+		if o, ok := mp.claims[c]; ok {
+			if o.priority >= priority {
+				return ErrConflict
+			}
+			conflicts[o.tx.Digest.String()] = o
+		}
+	}
+
+	// Find eviction candidates from the lowest priority up, ignoring txs
+	// already being replaced
+	var (
+		count   = len(mp.txs) - len(conflicts) + 1
+		size    = mp.size + e.size
+		evicted = make([]*entry, 0)
+	)
+	for _, o := range conflicts {
+		size -= o.size
+	}
+
+	sorted := mp.sorted()
+	for i := len(sorted) - 1; i >= 0 && mp.overLimits(count, size); i-- {
+		o := sorted[i]
+		if _, ok := conflicts[o.tx.Digest.String()]; ok {
+			continue
+		}
+		if o.priority >= priority {
+			return ErrPoolFull
+		}
+
+		evicted = append(evicted, o)
+		count--
+		size -= o.size
+	}
+
+	for _, o := range conflicts {
+		mp.remove(o)
+	}
+	for _, o := range evicted {
+		mp.remove(o)
+	}
+
+	mp.seq++
+	e.seq = mp.seq
+	mp.insert(e)
+
+	return nil
+}
+
+// Get returns the pending tx by digest or nil if it is not in the pool
+func (mp *Mempool) Get(digest bcpb.Digest) *bcpb.Tx {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if e, ok := mp.txs[digest.String()]; ok {
+		return e.tx
+	}
+	return nil
+}
+
+// Len returns the number of pending txs
+func (mp *Mempool) Len() int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	return len(mp.txs)
+}
+
+// Size returns the total size in bytes of all pending txs
+func (mp *Mempool) Size() int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	return mp.size
+}
+
+// Reap returns up to max pending txs in priority and then arrival order.  The
+// txs remain in the pool until they are removed or committed.  A max less
+// than 1 returns all txs
+func (mp *Mempool) Reap(max int) []*bcpb.Tx {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	sorted := mp.sorted()
+	if max > 0 && max < len(sorted) {
+		sorted = sorted[:max]
+	}
+
+	txs := make([]*bcpb.Tx, len(sorted))
+	for i, e := range sorted {
+		txs[i] = e.tx
+	}
+	return txs
+}
+
+// Remove removes the txs by the given digests
+func (mp *Mempool) Remove(digests ...bcpb.Digest) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, d := range digests {
+		if e, ok := mp.txs[d.String()]; ok {
+			mp.remove(e)
+		}
+	}
+}
+
+// Update is called once a block has been committed.  It removes all txs
+// included in the block and re-validates the remaining txs against the ledger
+// dropping those that are no longer valid e.g. because they spend an output
+// spent by the block
+func (mp *Mempool) Update(blk *bcpb.Block) {
+	mp.Remove(blk.Txs...)
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, e := range mp.sorted() {
+		if err := mp.ledger.ValidateTx(e.tx); err != nil {
+			mp.remove(e)
+		}
+	}
+}
+
+func (mp *Mempool) overLimits(count, size int) bool {
+	return (mp.conf.MaxTxs > 0 && count > mp.conf.MaxTxs) ||
+		(mp.conf.MaxBytes > 0 && size > mp.conf.MaxBytes)
+}
+
+// sorted returns all entries in priority and then arrival order
+func (mp *Mempool) sorted() []*entry {
+	entries := make([]*entry, 0, len(mp.txs))
+	for _, e := range mp.txs {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].before(entries[j])
+	})
+
+	return entries
+}
+
+func (mp *Mempool) insert(e *entry) {
+	mp.txs[e.tx.Digest.String()] = e
+	for _, c := range e.claims {
+		mp.claims[c] = e
+	}
+	mp.size += e.size
+}
+
+func (mp *Mempool) remove(e *entry) {
+	delete(mp.txs, e.tx.Digest.String())
+	for _, c := range e.claims {
+		if mp.claims[c] == e {
+			delete(mp.claims, c)
+		}
+	}
+	mp.size -= e.size
+}
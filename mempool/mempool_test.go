@@ -0,0 +1,112 @@
+package mempool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain"
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+type testLedger struct {
+	spent map[string]bool
+}
+
+func (l *testLedger) ValidateTx(tx *bcpb.Tx) error {
+	for _, in := range tx.Inputs {
+		if l.spent[in.Ref.String()] {
+			return errors.New("tx already spent")
+		}
+	}
+	return nil
+}
+
+func testTx(ref bcpb.Digest, key string, data string) *bcpb.Tx {
+	tx := bcpb.NewTx()
+	if ref == nil {
+		tx.AddInput(bcpb.NewBaseTxInput())
+	} else {
+		tx.AddInput(bcpb.NewTxInput(ref, 0, nil))
+	}
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key), Data: []byte(data)})
+	tx.SetDigest(hasher.Default())
+	return tx
+}
+
+func Test_Mempool(t *testing.T) {
+	ledger := &testLedger{spent: make(map[string]bool)}
+	mp := New(&Config{MaxTxs: 3}, ledger)
+
+	ref := bcpb.NewDigest("sha256", []byte("ref"))
+
+	tx1 := testTx(ref, "mp:a", "1")
+	assert.Nil(t, mp.Add(tx1, 1))
+	assert.Equal(t, ErrTxExists, mp.Add(tx1, 1))
+
+	// Same output at same priority
+	tx2 := testTx(ref, "mp:b", "2")
+	assert.Equal(t, ErrConflict, mp.Add(tx2, 1))
+
+	// Same DataKey at same priority
+	tx3 := testTx(nil, "mp:a", "3")
+	assert.Equal(t, ErrConflict, mp.Add(tx3, 1))
+
+	// Higher priority replaces
+	assert.Nil(t, mp.Add(tx2, 2))
+	assert.Nil(t, mp.Get(tx1.Digest))
+	assert.NotNil(t, mp.Get(tx2.Digest))
+	assert.Nil(t, mp.Add(tx3, 1))
+	assert.Equal(t, 2, mp.Len())
+
+	// Ordered by priority then arrival
+	tx4 := testTx(nil, "mp:c", "4")
+	assert.Nil(t, mp.Add(tx4, 1))
+	txs := mp.Reap(0)
+	assert.Equal(t, []*bcpb.Tx{tx2, tx3, tx4}, txs)
+	assert.Equal(t, 1, len(mp.Reap(1)))
+
+	// Full.  Equal priority is rejected while higher evicts the newest lowest
+	tx5 := testTx(nil, "mp:d", "5")
+	assert.Equal(t, ErrPoolFull, mp.Add(tx5, 1))
+	assert.Nil(t, mp.Add(tx5, 3))
+	assert.Equal(t, []*bcpb.Tx{tx5, tx2, tx3}, mp.Reap(0))
+
+	// Committed block drops included and now invalid txs
+	ledger.spent[ref.String()] = true
+	blk := bcpb.NewBlock()
+	blk.SetTxs([]*bcpb.Tx{tx5}, hasher.Default())
+	mp.Update(blk)
+	assert.Equal(t, []*bcpb.Tx{tx3}, mp.Reap(0))
+	assert.Equal(t, tx3.Size(), mp.Size())
+
+	// Invalid txs are not accepted
+	assert.NotNil(t, mp.Add(testTx(ref, "mp:e", "6"), 10))
+}
+
+func Test_Mempool_Watch(t *testing.T) {
+	var _ Subscriber = (*blockchain.Blockchain)(nil)
+
+	ledger := &testLedger{spent: make(map[string]bool)}
+	mp := New(DefaultConfig(), ledger)
+
+	ref := bcpb.NewDigest("sha256", []byte("ref"))
+	tx1 := testTx(ref, "mp:a", "1")
+	tx2 := testTx(nil, "mp:b", "2")
+	assert.Nil(t, mp.Add(tx1, 1))
+	assert.Nil(t, mp.Add(tx2, 1))
+
+	blk := bcpb.NewBlock()
+	blk.SetTxs([]*bcpb.Tx{tx2}, hasher.Default())
+
+	ledger.spent[ref.String()] = true
+	events := make(chan blockchain.Event, 2)
+	events <- blockchain.Event{Type: blockchain.EventBlockAppended, Block: blk}
+	events <- blockchain.Event{Type: blockchain.EventBlockCommitted, Block: blk}
+	close(events)
+
+	mp.Watch(events)
+	assert.Equal(t, 0, mp.Len())
+}
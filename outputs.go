@@ -0,0 +1,65 @@
+package blockchain
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// Output is an unspent output along with the tx and index it is at
+type Output struct {
+	Ref      bcpb.Digest
+	Index    int32
+	TxOutput *bcpb.TxOutput
+}
+
+// OutputsFor returns the unspent outputs on the main chain that list the public
+// key.  Outputs without public keys can be unlocked by anyone and are not
+// included.  See OutputsForAddress for pagination
+func (bc *Blockchain) OutputsFor(pubkey bcpb.PublicKey, cursor []byte, limit int) ([]*Output, []byte, error) {
+	return bc.OutputsForAddress(pubkey.Address(bc.h), cursor, limit)
+}
+
+// OutputsForAddress returns up to limit unspent outputs on the main chain
+// listing a public key with the given address.  A limit of zero or less returns
+// all outputs.  The returned cursor is passed in to get the next page and is
+// nil once there are no more outputs.  A nil cursor starts at the first output
+func (bc *Blockchain) OutputsForAddress(addr []byte, cursor []byte, limit int) ([]*Output, []byte, error) {
+	var (
+		outs = make([]*Output, 0)
+		more bool
+		err  error
+	)
+
+	ierr := bc.tx.utxo.IterAddress(addr, cursor, func(ref bcpb.Digest, idx int32) bool {
+		if limit > 0 && len(outs) == limit {
+			more = true
+			return false
+		}
+
+		var tx *bcpb.Tx
+		if tx, err = bc.tx.Get(ref); err != nil {
+			return false
+		}
+		if int(idx) >= len(tx.Outputs) {
+			err = errInvalidOutputIndex
+			return false
+		}
+
+		outs = append(outs, &Output{Ref: ref, Index: idx, TxOutput: tx.Outputs[idx]})
+		return true
+	})
+
+	if ierr != nil {
+		return nil, nil, ierr
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	var next []byte
+	if more {
+		last := outs[len(outs)-1]
+		next = stores.OutPoint(last.Ref, last.Index)
+	}
+
+	return outs, next, nil
+}
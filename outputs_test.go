@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+func Test_Blockchain_OutputsFor(t *testing.T) {
+	conf := testBlockchainConfPrefix("outputs/")
+	bc := New(conf)
+
+	kp1, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	kp2, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtx := bcpb.NewBaseTx()
+	for _, k := range []string{"out:a", "out:b", "out:c"} {
+		gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(k), PubKeys: []bcpb.PublicKey{kp1.PublicKey}})
+	}
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("out:d"), PubKeys: []bcpb.PublicKey{kp2.PublicKey}})
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kp1)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	outs, next, err := bc.OutputsFor(kp1.PublicKey, nil, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 3, len(outs))
+
+	// Paginate
+	outs, next, err = bc.OutputsFor(kp1.PublicKey, nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(outs))
+	assert.NotNil(t, next)
+	assert.Equal(t, bcpb.DataKey("out:a"), outs[0].TxOutput.DataKey)
+
+	outs, next, err = bc.OutputsFor(kp1.PublicKey, next, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 1, len(outs))
+	assert.Equal(t, int32(2), outs[0].Index)
+
+	outs, _, _ = bc.OutputsForAddress(kp2.PublicKey.Address(bc.Hasher()), nil, 0)
+	assert.Equal(t, 1, len(outs))
+
+	// Move out:a to kp2
+	txi, _ := bc.NewTxInput(bcpb.DataKey("out:a"))
+	tx := bcpb.NewTx()
+	tx.AddInput(txi)
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("out:a"), PubKeys: []bcpb.PublicKey{kp2.PublicKey}})
+	testSignInput(bc, tx, kp1)
+
+	txs := []*bcpb.Tx{tx}
+	a1 := testSignedBlock(bc, genesis, txs, kp1)
+	_, err = bc.Append(a1, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	outs, _, _ = bc.OutputsFor(kp1.PublicKey, nil, 0)
+	assert.Equal(t, 2, len(outs))
+	outs, _, _ = bc.OutputsFor(kp2.PublicKey, nil, 0)
+	assert.Equal(t, 2, len(outs))
+
+	// Reverting restores the previous owner
+	assert.Nil(t, bc.Rewind(0))
+	outs, _, _ = bc.OutputsFor(kp1.PublicKey, nil, 0)
+	assert.Equal(t, 3, len(outs))
+	outs, _, _ = bc.OutputsFor(kp2.PublicKey, nil, 0)
+	assert.Equal(t, 1, len(outs))
+}
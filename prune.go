@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"errors"
+	"log"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// ErrPruneDepth is returned when rewinding or reorging more blocks than the
+// prune depth as the txs they spend may have been pruned
+var ErrPruneDepth = errors.New("beyond prune depth")
+
+// prune removes the bodies of txs whose outputs have all been spent by main
+// chain blocks at least pruneDepth below the last block.  The main chain blocks
+// from height from up to the last block at height to have just been committed
+// so the blocks pruneDepth below each of them are swept.  A tx can only become
+// prunable in the block it is in or the block spending its last output
+// so only the txs of the swept blocks and the txs they spend are checked.
+// Outputs spent by blocks less than pruneDepth deep are treated as unspent as
+// those blocks may still be reverted
+func (bc *Blockchain) prune(from, to uint32) error {
+	n := bc.pruneDepth
+	if n == 0 || to < n {
+		return nil
+	}
+	if from < n {
+		from = n
+	}
+
+	// Blocks from the last block down to, but excluding, the lowest one swept
+	lid, _ := bc.blk.st.Last()
+	path, err := bc.blk.walkBack(lid, from-n)
+	if err != nil {
+		return err
+	}
+	lowest := bc.blk.get(path[len(path)-1].Header.PrevBlock)
+	if lowest == nil {
+		return stores.ErrBlockNotFound
+	}
+
+	// Outputs spent by blocks that may still be reverted
+	recent := make(map[string]struct{})
+	for _, blk := range path[:n] {
+		txs, err := bc.getBlockTxs(blk)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			for _, in := range tx.Inputs {
+				if !in.IsBase() {
+					recent[string(stores.OutPoint(in.Ref, in.Index))] = struct{}{}
+				}
+			}
+		}
+	}
+
+	candidates := make(map[string]bcpb.Digest)
+	for _, blk := range append(path[n:], lowest) {
+		for _, tid := range blk.Txs {
+			tx, err := bc.tx.Get(tid)
+			if err == stores.ErrPruned {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			candidates[tid.String()] = tid
+			for _, in := range tx.Inputs {
+				if !in.IsBase() {
+					candidates[in.Ref.String()] = in.Ref
+				}
+			}
+		}
+	}
+
+	batch := bc.batcher.NewBatch()
+	defer batch.Discard()
+
+	for _, tid := range candidates {
+		ok, err := bc.prunable(tid, recent)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err = bc.tx.tx.Prune(batch, tid); err != nil {
+			return err
+		}
+	}
+
+	return batch.Commit()
+}
+
+// sweep prunes the txs made prunable by committing the main chain blocks from
+// height from up to to.  The blocks are already committed so a failure only
+// leaves tx bodies in place and is logged rather than returned
+func (bc *Blockchain) sweep(from, to uint32) {
+	if err := bc.prune(from, to); err != nil {
+		log.Printf("[ERR] Failed to prune height=%d-%d: %v", from, to, err)
+	}
+}
+
+// checkPruneDepth returns ErrPruneDepth if the number of main chain blocks to
+// revert is more than the prune depth in pruned mode
+func (bc *Blockchain) checkPruneDepth(n uint32) error {
+	if bc.pruneDepth != 0 && n > bc.pruneDepth {
+		return ErrPruneDepth
+	}
+	return nil
+}
+
+// prunable returns true if none of the outputs of the tx are unspent or spent
+// by a recent block.  Txs that have already been pruned are not prunable
+func (bc *Blockchain) prunable(tid bcpb.Digest, recent map[string]struct{}) (bool, error) {
+	tx, err := bc.tx.Get(tid)
+	if err == stores.ErrPruned {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for i := range tx.Outputs {
+		if bc.tx.utxo.Exists(tid, int32(i)) {
+			return false, nil
+		}
+		if _, ok := recent[string(stores.OutPoint(tid, int32(i)))]; ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
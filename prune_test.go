@@ -0,0 +1,171 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func Test_Blockchain_Prune(t *testing.T) {
+	conf := testBlockchainConfPrefix("prune/")
+	conf.PruneDepth = 2
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtx := testBaseTx(bc, "prune:a", "prune:b")
+	genesis := testSignedBlock(bc, nil, []*bcpb.Tx{gtx}, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, []*bcpb.Tx{gtx}))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	commit := func(prev *bcpb.Block, txs ...*bcpb.Tx) *bcpb.Block {
+		blk := testSignedBlock(bc, prev, txs, kp)
+		_, err := bc.Append(blk, txs)
+		assert.Nil(t, err)
+		assert.Nil(t, bc.Commit(blk.Digest))
+		return blk
+	}
+
+	// The genesis tx is fully spent at height 2
+	atx := testUpdateTx(t, bc, "prune:a", "1")
+	b1 := commit(genesis, atx)
+	b2 := commit(b1, testUpdateTx(t, bc, "prune:b", "1"))
+	b3 := commit(b2, testBaseTx(bc, "prune:c"))
+
+	_, err := bc.tx.Get(gtx.Digest)
+	assert.Nil(t, err)
+
+	// Spending block is now 2 deep
+	commit(b3, testBaseTx(bc, "prune:d"))
+
+	_, err = bc.tx.Get(gtx.Digest)
+	assert.Equal(t, stores.ErrPruned, err)
+
+	// Partially spent or unspent txs are kept
+	_, err = bc.tx.Get(atx.Digest)
+	assert.Nil(t, err)
+	out, err := bc.GetTXOByDataKey(bcpb.DataKey("prune:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+
+	// History of pruned txs has no output and the chain cannot be exported
+	versions, err := bc.DataKeyHistory(bcpb.DataKey("prune:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(versions))
+	assert.True(t, versions[0].Pruned)
+	assert.Nil(t, versions[0].TxOutput)
+	assert.False(t, versions[1].Pruned)
+	assert.Equal(t, []byte("1"), versions[1].TxOutput.Data)
+
+	_, err = bc.GetTXOByDataKeyAt(bcpb.DataKey("prune:a"), 0)
+	assert.Equal(t, stores.ErrPruned, err)
+
+	var buf bytes.Buffer
+	assert.Equal(t, ErrExportPruned, bc.Export(&buf))
+	assert.Equal(t, 0, buf.Len())
+
+	// Block digests are kept and the ledger can still be rewound within the
+	// prune depth but not beyond it
+	assert.Equal(t, gtx.Digest, bc.Genesis().Txs[0])
+	assert.Equal(t, ErrPruneDepth, bc.Rewind(b1.Height()))
+	assert.Equal(t, ErrPruneDepth, bc.Reorg(b1.Digest))
+	assert.Equal(t, b3.Height()+1, bc.Last().Height())
+	assert.Nil(t, bc.Rewind(b2.Height()))
+}
+
+// failPruneTxStorage fails every prune
+type failPruneTxStorage struct {
+	TxStorage
+}
+
+func (st *failPruneTxStorage) Prune(stores.Batch, bcpb.Digest) error {
+	return errors.New("prune failed")
+}
+
+func Test_Blockchain_PruneFailure(t *testing.T) {
+	conf := testBlockchainConfPrefix("prunefail/")
+	conf.PruneDepth = 1
+	conf.TxStorage = &failPruneTxStorage{conf.TxStorage}
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "prunefail:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// The genesis tx becomes prunable but the commits still succeed
+	prev := genesis
+	for _, data := range []string{"1", "2"} {
+		txs := []*bcpb.Tx{testUpdateTx(t, bc, "prunefail:a", data)}
+		blk := testSignedBlock(bc, prev, txs, kp)
+		_, err := bc.Append(blk, txs)
+		assert.Nil(t, err)
+		assert.Nil(t, bc.Commit(blk.Digest))
+		prev = blk
+	}
+
+	assert.Equal(t, prev.Digest, bc.Last().Digest)
+	_, err := bc.tx.Get(gtxs[0].Digest)
+	assert.Nil(t, err)
+}
+
+func Test_Blockchain_PruneSignerSet(t *testing.T) {
+	conf := testBlockchainConfPrefix("prunesigners/")
+	conf.PruneDepth = 1
+	bc := New(conf)
+	bc.SetBlockValidator(bc.ValidateSigners)
+
+	kps := make([]*keypair.KeyPair, 2)
+	for i := range kps {
+		kps[i], _ = keypair.Generate(conf.Curve, conf.Hasher)
+	}
+	set1 := &bcpb.SignerSet{Signers: []bcpb.PublicKey{kps[0].PublicKey}, N: 1, S: 1, Q: 1}
+	set2 := &bcpb.SignerSet{Signers: []bcpb.PublicKey{kps[1].PublicKey}, N: 1, S: 1, Q: 1}
+
+	txo, err := NewSignerSetOutput(set1)
+	assert.Nil(t, err)
+	gtx := testBaseTx(bc)
+	gtx.AddOutput(txo)
+	gtx.SetDigest(bc.Hasher())
+
+	genesis := testSignedBlock(bc, nil, []*bcpb.Tx{gtx}, kps[0])
+	assert.Nil(t, bc.SetGenesis(genesis, []*bcpb.Tx{gtx}))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// Replace the genesis set then commit past the prune depth
+	rtx, err := bc.NewSignerSetTx(set2)
+	assert.Nil(t, err)
+	testSignInput(bc, rtx, kps[0])
+	b1 := testNextSignedBlock(t, bc, set1, []*bcpb.Tx{rtx}, kps[0])
+	id, err := bc.Append(b1, []*bcpb.Tx{rtx})
+	assert.Nil(t, err)
+	testCommit(t, bc, id, kps[0])
+
+	for i := 0; i < 2; i++ {
+		blk := testNextSignedBlock(t, bc, set2, nil, kps[1])
+		id, err = bc.Append(blk, nil)
+		assert.Nil(t, err)
+		testCommit(t, bc, id, kps[1])
+	}
+
+	_, err = bc.tx.Get(gtx.Digest)
+	assert.Equal(t, stores.ErrPruned, err)
+
+	// The replaced set is still available for past heights
+	set, err := bc.SignerSetAt(b1.Height())
+	assert.Nil(t, err)
+	assert.Equal(t, set1.Signers, set.Signers)
+	assert.Nil(t, bc.ValidateSigners(b1.Header))
+
+	set, err = bc.SignerSetAt(b1.Height() + 1)
+	assert.Nil(t, err)
+	assert.Equal(t, set2.Signers, set.Signers)
+}
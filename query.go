@@ -0,0 +1,186 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+var errEmptyQuery = errors.New("query has no predicate")
+
+// Predicate selects DataKeys by the Tags, Labels and Metrics of their current
+// output.  Predicates are built with TagEquals, HasLabel, MetricRange, And and
+// Or
+type Predicate interface {
+	// keys returns the set of DataKeys matching the predicate
+	keys(QueryIndex) (map[string]struct{}, error)
+}
+
+type tagPredicate struct{ name, value string }
+
+func (p tagPredicate) keys(qi QueryIndex) (map[string]struct{}, error) {
+	set := make(map[string]struct{})
+	err := qi.IterTag(p.name, p.value, collectKeys(set))
+	return set, err
+}
+
+type labelPredicate string
+
+func (p labelPredicate) keys(qi QueryIndex) (map[string]struct{}, error) {
+	set := make(map[string]struct{})
+	err := qi.IterLabel(string(p), collectKeys(set))
+	return set, err
+}
+
+type metricPredicate struct {
+	name     string
+	min, max float64
+}
+
+func (p metricPredicate) keys(qi QueryIndex) (map[string]struct{}, error) {
+	set := make(map[string]struct{})
+	err := qi.IterMetric(p.name, p.min, p.max, collectKeys(set))
+	return set, err
+}
+
+type andPredicate []Predicate
+
+func (p andPredicate) keys(qi QueryIndex) (map[string]struct{}, error) {
+	if len(p) == 0 {
+		return nil, errEmptyQuery
+	}
+
+	set, err := p[0].keys(qi)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pred := range p[1:] {
+		if len(set) == 0 {
+			break
+		}
+
+		other, err := pred.keys(qi)
+		if err != nil {
+			return nil, err
+		}
+		for k := range set {
+			if _, ok := other[k]; !ok {
+				delete(set, k)
+			}
+		}
+	}
+
+	return set, nil
+}
+
+type orPredicate []Predicate
+
+func (p orPredicate) keys(qi QueryIndex) (map[string]struct{}, error) {
+	if len(p) == 0 {
+		return nil, errEmptyQuery
+	}
+
+	set := make(map[string]struct{})
+	for _, pred := range p {
+		other, err := pred.keys(qi)
+		if err != nil {
+			return nil, err
+		}
+		for k := range other {
+			set[k] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+func collectKeys(set map[string]struct{}) func(bcpb.DataKey) bool {
+	return func(key bcpb.DataKey) bool {
+		set[string(key)] = struct{}{}
+		return true
+	}
+}
+
+// TagEquals matches outputs with the tag set to the value
+func TagEquals(name, value string) Predicate {
+	return tagPredicate{name, value}
+}
+
+// HasLabel matches outputs with the label
+func HasLabel(label string) Predicate {
+	return labelPredicate(label)
+}
+
+// MetricRange matches outputs with the metric within min and max inclusive
+func MetricRange(name string, min, max float64) Predicate {
+	return metricPredicate{name, min, max}
+}
+
+// And matches outputs matching all predicates
+func And(preds ...Predicate) Predicate {
+	return andPredicate(preds)
+}
+
+// Or matches outputs matching any of the predicates
+func Or(preds ...Predicate) Predicate {
+	return orPredicate(preds)
+}
+
+// Query selects the current outputs of DataKeys on the main chain
+type Query struct {
+	// Predicate the outputs must match.  This is required
+	Where Predicate
+	// Only match DataKeys with this prefix e.g. a DataKey type followed by
+	// ':'.  Optional
+	Prefix bcpb.DataKey
+	// Max number of outputs to return.  Zero or less returns all
+	Limit int
+	// Cursor returned by the previous page.  Nil starts at the first output
+	Cursor []byte
+}
+
+// Query returns the outputs matching the query in DataKey order.  The returned
+// cursor is set in the query to get the next page and is nil once there are no
+// more outputs
+func (bc *Blockchain) Query(q *Query) ([]*Output, []byte, error) {
+	if q.Where == nil {
+		return nil, nil, errEmptyQuery
+	}
+
+	set, err := q.Where.keys(bc.tx.qi)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		if !bytes.HasPrefix([]byte(k), q.Prefix) {
+			continue
+		}
+		if q.Cursor != nil && k <= string(q.Cursor) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var next []byte
+	if q.Limit > 0 && len(keys) > q.Limit {
+		keys = keys[:q.Limit]
+		next = []byte(keys[len(keys)-1])
+	}
+
+	outs := make([]*Output, len(keys))
+	for i, k := range keys {
+		tx, idx, err := bc.tx.GetDataKeyTx(bcpb.DataKey(k))
+		if err != nil {
+			return nil, nil, err
+		}
+		outs[i] = &Output{Ref: tx.Digest, Index: idx, TxOutput: tx.Outputs[idx]}
+	}
+
+	return outs, next, nil
+}
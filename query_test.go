@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+func Test_Blockchain_Query(t *testing.T) {
+	conf := testBlockchainConfPrefix("query/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtx := bcpb.NewBaseTx()
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("car:1"), Tags: map[string]string{"color": "red"}, Metrics: map[string]float64{"price": 100}})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("car:2"), Tags: map[string]string{"color": "red"}, Labels: []string{"sold"}, Metrics: map[string]float64{"price": 200}})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("car:3"), Tags: map[string]string{"color": "blue"}, Metrics: map[string]float64{"price": 300}})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("bike:1"), Tags: map[string]string{"color": "red"}})
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	keys := func(outs []*Output) []string {
+		out := make([]string, len(outs))
+		for i, o := range outs {
+			out[i] = string(o.TxOutput.DataKey)
+		}
+		return out
+	}
+
+	_, _, err := bc.Query(&Query{})
+	assert.Equal(t, errEmptyQuery, err)
+
+	outs, next, err := bc.Query(&Query{Where: TagEquals("color", "red")})
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, []string{"bike:1", "car:1", "car:2"}, keys(outs))
+
+	outs, _, _ = bc.Query(&Query{Where: TagEquals("color", "red"), Prefix: bcpb.DataKey("car:")})
+	assert.Equal(t, []string{"car:1", "car:2"}, keys(outs))
+
+	outs, _, _ = bc.Query(&Query{Where: And(TagEquals("color", "red"), MetricRange("price", 150, 1000))})
+	assert.Equal(t, []string{"car:2"}, keys(outs))
+
+	outs, _, _ = bc.Query(&Query{Where: Or(HasLabel("sold"), TagEquals("color", "blue"))})
+	assert.Equal(t, []string{"car:2", "car:3"}, keys(outs))
+
+	// Paginate
+	q := &Query{Where: MetricRange("price", 0, 1000), Limit: 2}
+	outs, next, _ = bc.Query(q)
+	assert.Equal(t, []string{"car:1", "car:2"}, keys(outs))
+	assert.NotNil(t, next)
+	q.Cursor = next
+	outs, next, _ = bc.Query(q)
+	assert.Equal(t, []string{"car:3"}, keys(outs))
+	assert.Nil(t, next)
+
+	// Updates move the DataKey to the new attributes
+	txi, _ := bc.NewTxInput(bcpb.DataKey("car:1"))
+	tx := bcpb.NewTx()
+	tx.AddInput(txi)
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("car:1"), Tags: map[string]string{"color": "blue"}})
+	tx.SetDigest(bc.Hasher())
+
+	txs := []*bcpb.Tx{tx}
+	a1 := testSignedBlock(bc, genesis, txs, kp)
+	_, err = bc.Append(a1, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	outs, _, _ = bc.Query(&Query{Where: TagEquals("color", "blue")})
+	assert.Equal(t, []string{"car:1", "car:3"}, keys(outs))
+	assert.Equal(t, tx.Digest, outs[0].Ref)
+	outs, _, _ = bc.Query(&Query{Where: MetricRange("price", 0, 150)})
+	assert.Equal(t, 0, len(outs))
+
+	// Reverting restores them
+	assert.Nil(t, bc.Rewind(0))
+	outs, _, _ = bc.Query(&Query{Where: TagEquals("color", "blue")})
+	assert.Equal(t, []string{"car:3"}, keys(outs))
+	outs, _, _ = bc.Query(&Query{Where: MetricRange("price", 0, 150)})
+	assert.Equal(t, []string{"car:1"}, keys(outs))
+}
@@ -0,0 +1,64 @@
+package script
+
+import "encoding/binary"
+
+// Builder builds a script using the minimal encoding for each push
+type Builder struct {
+	script []byte
+}
+
+// NewBuilder returns a new empty script Builder
+func NewBuilder() *Builder {
+	return &Builder{script: make([]byte, 0)}
+}
+
+// AddOp appends the opcodes
+func (b *Builder) AddOp(ops ...byte) *Builder {
+	b.script = append(b.script, ops...)
+	return b
+}
+
+// AddData appends a push of the data
+func (b *Builder) AddData(data []byte) *Builder {
+	l := len(data)
+
+	switch {
+	case l == 0:
+		b.script = append(b.script, Op0)
+
+	case l < int(OpPushData1):
+		b.script = append(b.script, byte(l))
+
+	case l <= 0xff:
+		b.script = append(b.script, OpPushData1, byte(l))
+
+	default:
+		n := make([]byte, 2)
+		binary.BigEndian.PutUint16(n, uint16(l))
+		b.script = append(append(b.script, OpPushData2), n...)
+	}
+
+	b.script = append(b.script, data...)
+	return b
+}
+
+// AddInt appends a push of the number.  0 through 16 are pushed using a single
+// opcode
+func (b *Builder) AddInt(n uint64) *Builder {
+	if n >= 1 && n <= 16 {
+		b.script = append(b.script, Op1+byte(n-1))
+		return b
+	}
+	return b.AddData(encodeNumber(n))
+}
+
+// AddArg appends a push of the input argument at index i
+func (b *Builder) AddArg(i uint8) *Builder {
+	b.script = append(b.script, OpArg, i)
+	return b
+}
+
+// Script returns the built script
+func (b *Builder) Script() []byte {
+	return b.script
+}
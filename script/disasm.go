@@ -0,0 +1,42 @@
+package script
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Disassemble returns the human readable form of the script.  Pushed data is
+// shown as hex and undefined opcodes as OP_UNKNOWN followed by their value.
+// On error the script disassembled up to the failing opcode is returned
+func Disassemble(script []byte) (string, error) {
+	parts := make([]string, 0)
+
+	for pc := 0; pc < len(script); {
+		op, data, npc, err := next(script, pc)
+		if err != nil {
+			return strings.Join(parts, " "), err
+		}
+		pc = npc
+
+		switch {
+		case isPush(op):
+			parts = append(parts, "0x"+hex.EncodeToString(data))
+
+		case isSmallInt(op):
+			parts = append(parts, fmt.Sprintf("OP_%d", op-Op1+1))
+
+		case op == OpArg:
+			parts = append(parts, fmt.Sprintf("OP_ARG %d", data[0]))
+
+		default:
+			name, ok := opNames[op]
+			if !ok {
+				name = fmt.Sprintf("OP_UNKNOWN_0x%02x", op)
+			}
+			parts = append(parts, name)
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
@@ -0,0 +1,29 @@
+package script
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Disassemble(t *testing.T) {
+	s := NewBuilder().
+		AddInt(2).AddData([]byte{0xab, 0xcd}).AddData(make([]byte, 80)[:1]).AddInt(2).AddOp(OpCheckSigs).
+		AddArg(3).AddInt(0).AddInt(300).AddOp(OpCheckHeightVerify, 0xff).
+		Script()
+
+	str, err := Disassemble(s)
+	assert.Nil(t, err)
+	assert.Equal(t, "OP_2 0xabcd 0x00 OP_2 OP_CHECKSIGS OP_ARG 3 OP_0 0x012c OP_CHECKHEIGHTVERIFY OP_UNKNOWN_0xff", str)
+
+	// Long pushes
+	s = NewBuilder().AddData(make([]byte, 100)).AddData(make([]byte, 300)).Script()
+	assert.Equal(t, OpPushData1, s[0])
+	assert.Equal(t, OpPushData2, s[102])
+	_, err = Disassemble(s)
+	assert.Nil(t, err)
+
+	str, err = Disassemble(append(NewBuilder().AddOp(OpDup).Script(), 0x02, 0x01))
+	assert.Equal(t, ErrTruncated, err)
+	assert.Equal(t, "OP_DUP", str)
+}
@@ -0,0 +1,350 @@
+package script
+
+import (
+	"bytes"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+// Context is the data a script is evaluated against
+type Context struct {
+	// Arguments of the input spending the output i.e. TxInput.Args()
+	Args [][]byte
+	// Public keys that have validly signed the input
+	Signers []bcpb.PublicKey
+	// Height of the block the spending tx is to be included in
+	Height uint32
+	// Chain time in unix nanoseconds
+	Time int64
+	// Hash function used by OP_HASH
+	Hasher hasher.Hasher
+}
+
+// Execute runs the script against the context.  It returns nil if the script
+// succeeds
+func Execute(script []byte, ctx *Context) error {
+	if len(script) > MaxScriptSize {
+		return ErrScriptTooLarge
+	}
+
+	vm := &machine{ctx: ctx, stack: make([][]byte, 0)}
+	return vm.run(script)
+}
+
+type machine struct {
+	ctx   *Context
+	stack [][]byte
+	// Branch taken by each enclosing conditional
+	cond []bool
+}
+
+// executing returns true if all enclosing conditionals are taken
+func (vm *machine) executing() bool {
+	return taken(vm.cond)
+}
+
+func taken(cond []bool) bool {
+	for _, c := range cond {
+		if !c {
+			return false
+		}
+	}
+	return true
+}
+
+func (vm *machine) push(b []byte) error {
+	if len(b) > MaxElementSize {
+		return ErrElementTooLarge
+	}
+	if len(vm.stack) >= MaxStackSize {
+		return ErrStackOverflow
+	}
+	vm.stack = append(vm.stack, b)
+	return nil
+}
+
+func (vm *machine) pop() ([]byte, error) {
+	if len(vm.stack) == 0 {
+		return nil, ErrStackUnderflow
+	}
+	b := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return b, nil
+}
+
+func (vm *machine) peek(depth int) ([]byte, error) {
+	if depth >= len(vm.stack) {
+		return nil, ErrStackUnderflow
+	}
+	return vm.stack[len(vm.stack)-1-depth], nil
+}
+
+func (vm *machine) popNumber() (uint64, error) {
+	b, err := vm.pop()
+	if err != nil {
+		return 0, err
+	}
+	return decodeNumber(b)
+}
+
+func (vm *machine) run(script []byte) error {
+	var steps int
+
+	for pc := 0; pc < len(script); {
+		if steps++; steps > MaxSteps {
+			return ErrStepLimit
+		}
+
+		op, data, npc, err := next(script, pc)
+		if err != nil {
+			return err
+		}
+		pc = npc
+
+		if err = vm.step(op, data); err != nil {
+			return err
+		}
+	}
+
+	if len(vm.cond) != 0 {
+		return ErrUnbalancedConditional
+	}
+
+	top, err := vm.peek(0)
+	if err != nil || !asBool(top) {
+		return ErrScriptFailed
+	}
+
+	return nil
+}
+
+// step executes a single opcode.  Conditionals are tracked even when not
+// executing so branches nest correctly
+func (vm *machine) step(op byte, data []byte) error {
+	switch op {
+	case OpIf, OpNotIf:
+		branch := false
+		if vm.executing() {
+			b, err := vm.pop()
+			if err != nil {
+				return err
+			}
+			branch = asBool(b) == (op == OpIf)
+		}
+		vm.cond = append(vm.cond, branch)
+		return nil
+
+	case OpElse:
+		i := len(vm.cond) - 1
+		if i < 0 {
+			return ErrUnbalancedConditional
+		}
+		// The branch can only be taken if all enclosing branches are
+		if taken(vm.cond[:i]) {
+			vm.cond[i] = !vm.cond[i]
+		}
+		return nil
+
+	case OpEndIf:
+		if len(vm.cond) == 0 {
+			return ErrUnbalancedConditional
+		}
+		vm.cond = vm.cond[:len(vm.cond)-1]
+		return nil
+	}
+
+	if !vm.executing() {
+		if _, ok := opNames[op]; !ok && !isPush(op) && !isSmallInt(op) {
+			return ErrInvalidOpcode
+		}
+		return nil
+	}
+
+	switch {
+	case op == Op0:
+		return vm.push([]byte{})
+
+	case isPush(op):
+		return vm.push(data)
+
+	case isSmallInt(op):
+		return vm.push([]byte{op - Op1 + 1})
+	}
+
+	switch op {
+	case OpVerify:
+		return vm.verify()
+
+	case OpReturn:
+		return ErrReturn
+
+	case OpDrop:
+		_, err := vm.pop()
+		return err
+
+	case OpDup, OpOver:
+		depth := 0
+		if op == OpOver {
+			depth = 1
+		}
+		b, err := vm.peek(depth)
+		if err != nil {
+			return err
+		}
+		return vm.push(b)
+
+	case OpSwap:
+		if len(vm.stack) < 2 {
+			return ErrStackUnderflow
+		}
+		l := len(vm.stack)
+		vm.stack[l-1], vm.stack[l-2] = vm.stack[l-2], vm.stack[l-1]
+		return nil
+
+	case OpEqual, OpEqualVerify:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if err = vm.push(fromBool(bytes.Equal(a, b))); err != nil {
+			return err
+		}
+		if op == OpEqualVerify {
+			return vm.verify()
+		}
+		return nil
+
+	case OpNot:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(fromBool(!asBool(b)))
+
+	case OpBoolAnd, OpBoolOr:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if op == OpBoolAnd {
+			return vm.push(fromBool(asBool(a) && asBool(b)))
+		}
+		return vm.push(fromBool(asBool(a) || asBool(b)))
+
+	case OpHash:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		hf := vm.ctx.Hasher.New()
+		hf.Write(b)
+		return vm.push(hf.Sum(nil))
+
+	case OpCheckSigs, OpCheckSigsVerify:
+		if err := vm.checkSigs(); err != nil {
+			return err
+		}
+		if op == OpCheckSigsVerify {
+			return vm.verify()
+		}
+		return nil
+
+	case OpArg:
+		i := int(data[0])
+		if i >= len(vm.ctx.Args) {
+			return ErrArgIndex
+		}
+		return vm.push(vm.ctx.Args[i])
+
+	case OpNumArgs:
+		return vm.push(encodeNumber(uint64(len(vm.ctx.Args))))
+
+	case OpCheckHeightVerify, OpCheckTimeVerify:
+		b, err := vm.peek(0)
+		if err != nil {
+			return err
+		}
+		n, err := decodeNumber(b)
+		if err != nil {
+			return err
+		}
+
+		if op == OpCheckHeightVerify && uint64(vm.ctx.Height) < n {
+			return ErrLockTime
+		}
+		if op == OpCheckTimeVerify && (vm.ctx.Time < 0 || uint64(vm.ctx.Time) < n) {
+			return ErrLockTime
+		}
+		return nil
+	}
+
+	return ErrInvalidOpcode
+}
+
+// verify pops the top item failing if it is false
+func (vm *machine) verify() error {
+	b, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	if !asBool(b) {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// checkSigs pops <m> <key 1> ... <key n> <n> and pushes true if at least m
+// distinct keys are signers of the input
+func (vm *machine) checkSigs() error {
+	n, err := vm.popNumber()
+	if err != nil {
+		return err
+	}
+	if n > MaxKeys {
+		return ErrInvalidNumber
+	}
+
+	keys := make([][]byte, n)
+	for i := range keys {
+		if keys[i], err = vm.pop(); err != nil {
+			return err
+		}
+	}
+
+	m, err := vm.popNumber()
+	if err != nil {
+		return err
+	}
+	if m > n {
+		return ErrInvalidNumber
+	}
+
+	var (
+		count uint64
+		seen  = make(map[string]struct{}, len(keys))
+	)
+	for _, key := range keys {
+		if _, ok := seen[string(key)]; ok {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+
+		for _, signer := range vm.ctx.Signers {
+			if signer.Equal(key) {
+				count++
+				break
+			}
+		}
+	}
+
+	return vm.push(fromBool(count >= m))
+}
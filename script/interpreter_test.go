@@ -0,0 +1,128 @@
+package script
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+func testHash(b []byte) []byte {
+	hf := hasher.Default().New()
+	hf.Write(b)
+	return hf.Sum(nil)
+}
+
+func Test_Execute(t *testing.T) {
+	key1, key2, key3 := []byte("key1"), []byte("key2"), []byte("key3")
+
+	ctx := &Context{
+		Args:    [][]byte{[]byte("secret"), []byte("other")},
+		Signers: []bcpb.PublicKey{key1, key3},
+		Height:  10,
+		Time:    1000,
+		Hasher:  hasher.Default(),
+	}
+
+	// 2 of 3 keys
+	multisig := func(m uint64) *Builder {
+		return NewBuilder().AddInt(m).AddData(key1).AddData(key2).AddData(key3).AddInt(3)
+	}
+
+	// Hash lock or a height lock refund
+	htlc := func(height uint64) []byte {
+		return NewBuilder().
+			AddArg(0).AddOp(OpHash).AddData(testHash([]byte("secret"))).AddOp(OpEqual).
+			AddOp(OpIf).
+			AddInt(1).
+			AddOp(OpElse).
+			AddInt(height).AddOp(OpCheckHeightVerify).
+			AddOp(OpEndIf).
+			Script()
+	}
+
+	cases := []struct {
+		name   string
+		script []byte
+		err    error
+	}{
+		{"true", NewBuilder().AddInt(1).Script(), nil},
+		{"false", NewBuilder().AddInt(0).Script(), ErrScriptFailed},
+		{"empty", []byte{}, ErrScriptFailed},
+		{"multisig", multisig(2).AddOp(OpCheckSigs).Script(), nil},
+		{"multisig unmet", multisig(3).AddOp(OpCheckSigs).Script(), ErrScriptFailed},
+		{"multisig verify", multisig(3).AddOp(OpCheckSigsVerify).AddInt(1).Script(), ErrVerifyFailed},
+		{"multisig m > n", multisig(4).AddOp(OpCheckSigs).Script(), ErrInvalidNumber},
+		{"duplicate keys", NewBuilder().AddInt(2).AddData(key1).AddData(key1).AddInt(2).AddOp(OpCheckSigs).Script(), ErrScriptFailed},
+		{"arg equal", NewBuilder().AddArg(1).AddData([]byte("other")).AddOp(OpEqual).Script(), nil},
+		{"arg not equal", NewBuilder().AddArg(0).AddData([]byte("other")).AddOp(OpEqualVerify).AddInt(1).Script(), ErrVerifyFailed},
+		{"arg index", NewBuilder().AddArg(2).Script(), ErrArgIndex},
+		{"num args", NewBuilder().AddOp(OpNumArgs).AddInt(2).AddOp(OpEqual).Script(), nil},
+		{"hash lock", htlc(100), nil},
+		{"height lock", NewBuilder().AddInt(10).AddOp(OpCheckHeightVerify).Script(), nil},
+		{"height lock unmet", NewBuilder().AddInt(11).AddOp(OpCheckHeightVerify).Script(), ErrLockTime},
+		{"time lock", NewBuilder().AddInt(1000).AddOp(OpCheckTimeVerify).Script(), nil},
+		{"time lock unmet", NewBuilder().AddInt(1001).AddOp(OpCheckTimeVerify).Script(), ErrLockTime},
+		{"not taken branch", NewBuilder().AddInt(0).AddOp(OpIf, OpReturn, OpElse).AddInt(1).AddOp(OpEndIf).Script(), nil},
+		{"nested", NewBuilder().AddInt(0).AddOp(OpIf).AddInt(1).AddOp(OpIf, OpElse, OpReturn, OpEndIf, OpEndIf).AddInt(1).Script(), nil},
+		{"notif", NewBuilder().AddInt(0).AddOp(OpNotIf).AddInt(1).AddOp(OpEndIf).Script(), nil},
+		{"bool ops", NewBuilder().AddInt(1).AddInt(0).AddOp(OpBoolOr).AddInt(0).AddOp(OpNot, OpBoolAnd).Script(), nil},
+		{"stack ops", NewBuilder().AddInt(1).AddInt(2).AddOp(OpSwap, OpDrop, OpDup, OpOver, OpEqual).Script(), nil},
+		{"return", NewBuilder().AddOp(OpReturn).Script(), ErrReturn},
+		{"unbalanced if", NewBuilder().AddInt(1).AddOp(OpIf).Script(), ErrUnbalancedConditional},
+		{"unbalanced endif", NewBuilder().AddOp(OpEndIf).Script(), ErrUnbalancedConditional},
+		{"underflow", NewBuilder().AddOp(OpDup).Script(), ErrStackUnderflow},
+		{"invalid opcode", []byte{0xff}, ErrInvalidOpcode},
+		{"invalid opcode not taken", NewBuilder().AddInt(0).AddOp(OpIf, 0xff, OpEndIf).Script(), ErrInvalidOpcode},
+		{"truncated", []byte{0x05, 0x01}, ErrTruncated},
+		{"truncated pushdata", []byte{OpPushData2, 0x01}, ErrTruncated},
+		{"invalid number", NewBuilder().AddData(make([]byte, 9)).AddOp(OpCheckHeightVerify).Script(), ErrInvalidNumber},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.err, Execute(c.script, ctx), c.name)
+	}
+
+	// Refund path once the height is reached without the secret
+	ctx.Args = [][]byte{[]byte("wrong")}
+	assert.Equal(t, ErrLockTime, Execute(htlc(100), ctx))
+	assert.Nil(t, Execute(htlc(10), ctx))
+}
+
+func Test_Execute_Limits(t *testing.T) {
+	ctx := &Context{Hasher: hasher.Default()}
+
+	assert.Equal(t, ErrScriptTooLarge, Execute(make([]byte, MaxScriptSize+1), ctx))
+
+	b := NewBuilder().AddInt(1)
+	for i := 0; i < MaxSteps; i++ {
+		b.AddOp(OpDup, OpDrop)
+	}
+	assert.Equal(t, ErrStepLimit, Execute(b.Script(), ctx))
+
+	b = NewBuilder()
+	for i := 0; i <= MaxStackSize; i++ {
+		b.AddInt(1)
+	}
+	assert.Equal(t, ErrStackOverflow, Execute(b.Script(), ctx))
+
+	big := bytes.Repeat([]byte{1}, MaxElementSize+1)
+	assert.Equal(t, ErrElementTooLarge, Execute(NewBuilder().AddData(big).Script(), ctx))
+}
+
+func Test_Logic(t *testing.T) {
+	s := NewBuilder().AddInt(1).Script()
+	logic := NewLogic(2, s)
+	assert.Equal(t, byte(2), logic[0])
+
+	parsed, ok := ParseLogic(logic)
+	assert.True(t, ok)
+	assert.Equal(t, s, parsed)
+
+	// Legacy single byte form
+	_, ok = ParseLogic([]byte{2})
+	assert.False(t, ok)
+}
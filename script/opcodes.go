@@ -0,0 +1,95 @@
+package script
+
+// Opcodes.  Values 0x01 through 0x4b push that many following bytes onto the
+// stack.
+const (
+	// Push an empty value i.e. false or zero
+	Op0 byte = 0x00
+	// Push the bytes whose length is given by the next byte
+	OpPushData1 byte = 0x4c
+	// Push the bytes whose length is given by the next 2 big endian bytes
+	OpPushData2 byte = 0x4d
+
+	// Push the numbers 1 through 16
+	Op1  byte = 0x51
+	Op16 byte = 0x60
+
+	// Flow control
+	OpIf     byte = 0x63
+	OpNotIf  byte = 0x64
+	OpElse   byte = 0x67
+	OpEndIf  byte = 0x68
+	OpVerify byte = 0x69
+	OpReturn byte = 0x6a
+
+	// Stack
+	OpDrop byte = 0x75
+	OpDup  byte = 0x76
+	OpOver byte = 0x78
+	OpSwap byte = 0x7c
+
+	// Comparison and logic
+	OpEqual       byte = 0x87
+	OpEqualVerify byte = 0x88
+	OpNot         byte = 0x91
+	OpBoolAnd     byte = 0x9a
+	OpBoolOr      byte = 0x9b
+
+	// Hash the top item with the chain hash function
+	OpHash byte = 0xa8
+
+	// <m> <key 1> ... <key n> <n> OP_CHECKSIGS pushes true if at least m of
+	// the keys signed the input
+	OpCheckSigs       byte = 0xae
+	OpCheckSigsVerify byte = 0xaf
+
+	// OP_ARG <i> pushes input argument i.  The index is the next byte
+	OpArg byte = 0xb0
+	// Push the number of input arguments
+	OpNumArgs byte = 0xb1
+
+	// <height> OP_CHECKHEIGHTVERIFY fails unless the chain height is at least
+	// height.  The value is left on the stack
+	OpCheckHeightVerify byte = 0xb2
+	// <time> OP_CHECKTIMEVERIFY fails unless the chain time is at least time,
+	// in unix nanoseconds.  The value is left on the stack
+	OpCheckTimeVerify byte = 0xb3
+)
+
+var opNames = map[byte]string{
+	Op0:                 "OP_0",
+	OpPushData1:         "OP_PUSHDATA1",
+	OpPushData2:         "OP_PUSHDATA2",
+	OpIf:                "OP_IF",
+	OpNotIf:             "OP_NOTIF",
+	OpElse:              "OP_ELSE",
+	OpEndIf:             "OP_ENDIF",
+	OpVerify:            "OP_VERIFY",
+	OpReturn:            "OP_RETURN",
+	OpDrop:              "OP_DROP",
+	OpDup:               "OP_DUP",
+	OpOver:              "OP_OVER",
+	OpSwap:              "OP_SWAP",
+	OpEqual:             "OP_EQUAL",
+	OpEqualVerify:       "OP_EQUALVERIFY",
+	OpNot:               "OP_NOT",
+	OpBoolAnd:           "OP_BOOLAND",
+	OpBoolOr:            "OP_BOOLOR",
+	OpHash:              "OP_HASH",
+	OpCheckSigs:         "OP_CHECKSIGS",
+	OpCheckSigsVerify:   "OP_CHECKSIGSVERIFY",
+	OpArg:               "OP_ARG",
+	OpNumArgs:           "OP_NUMARGS",
+	OpCheckHeightVerify: "OP_CHECKHEIGHTVERIFY",
+	OpCheckTimeVerify:   "OP_CHECKTIMEVERIFY",
+}
+
+// isPush returns true if the opcode pushes the bytes that follow it
+func isPush(op byte) bool {
+	return op <= OpPushData2 && op != Op0
+}
+
+// isSmallInt returns true if the opcode is one of OP_1 through OP_16
+func isSmallInt(op byte) bool {
+	return op >= Op1 && op <= Op16
+}
@@ -0,0 +1,159 @@
+// Package script implements a small stack based language used to express the
+// unlock conditions of a TxOutput.  Scripts are deterministic and bounded in
+// size, steps and stack usage.  A script succeeds if it runs to completion
+// leaving a true value on top of the stack.
+package script
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Tag identifies a script in TxOutput.Logic.  Logic is laid out as the legacy
+// required signatures byte, followed by the tag and the script
+const Tag byte = 0x01
+
+// Execution limits
+const (
+	// MaxScriptSize is the max script size in bytes
+	MaxScriptSize = 10000
+	// MaxSteps is the max number of opcodes processed
+	MaxSteps = 1000
+	// MaxStackSize is the max number of items on the stack
+	MaxStackSize = 256
+	// MaxElementSize is the max size of a single stack item
+	MaxElementSize = 520
+	// MaxKeys is the max number of keys checked by OP_CHECKSIGS
+	MaxKeys = 32
+)
+
+var (
+	// ErrScriptFailed is returned when a script completes without a true
+	// value on top of the stack
+	ErrScriptFailed = errors.New("script failed")
+	// ErrVerifyFailed is returned when a verify opcode fails
+	ErrVerifyFailed = errors.New("script verify failed")
+	// ErrLockTime is returned when a height or time lock has not been reached
+	ErrLockTime = errors.New("lock time not reached")
+	// ErrReturn is returned when OP_RETURN is executed
+	ErrReturn = errors.New("script returned")
+
+	// ErrScriptTooLarge is returned when a script exceeds MaxScriptSize
+	ErrScriptTooLarge = errors.New("script too large")
+	// ErrStepLimit is returned when a script exceeds MaxSteps
+	ErrStepLimit = errors.New("script step limit reached")
+	// ErrStackOverflow is returned when the stack exceeds MaxStackSize
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrStackUnderflow is returned when an opcode needs more stack items than
+	// available
+	ErrStackUnderflow = errors.New("stack underflow")
+	// ErrElementTooLarge is returned when pushing an item larger than
+	// MaxElementSize
+	ErrElementTooLarge = errors.New("stack element too large")
+	// ErrTruncated is returned when an opcode is missing the bytes following it
+	ErrTruncated = errors.New("script truncated")
+	// ErrInvalidOpcode is returned for undefined opcodes
+	ErrInvalidOpcode = errors.New("invalid opcode")
+	// ErrInvalidNumber is returned when an item is not a valid number
+	ErrInvalidNumber = errors.New("invalid number")
+	// ErrUnbalancedConditional is returned for OP_ELSE or OP_ENDIF without an
+	// OP_IF or an OP_IF without an OP_ENDIF
+	ErrUnbalancedConditional = errors.New("unbalanced conditional")
+	// ErrArgIndex is returned when OP_ARG references a missing argument
+	ErrArgIndex = errors.New("argument index out of range")
+)
+
+// NewLogic returns TxOutput.Logic holding the script.  The required signatures
+// are checked as with the legacy single byte form before the script is run
+func NewLogic(reqSigs uint8, script []byte) []byte {
+	return append([]byte{reqSigs, Tag}, script...)
+}
+
+// ParseLogic returns the script in TxOutput.Logic.  It returns false if the
+// logic does not hold a script
+func ParseLogic(logic []byte) ([]byte, bool) {
+	if len(logic) < 2 || logic[1] != Tag {
+		return nil, false
+	}
+	return logic[2:], true
+}
+
+// next returns the opcode at pc along with any data it carries and the
+// position of the following opcode
+func next(script []byte, pc int) (byte, []byte, int, error) {
+	op := script[pc]
+	pc++
+
+	var n int
+	switch {
+	case op == OpPushData1:
+		if pc+1 > len(script) {
+			return op, nil, pc, ErrTruncated
+		}
+		n = int(script[pc])
+		pc++
+
+	case op == OpPushData2:
+		if pc+2 > len(script) {
+			return op, nil, pc, ErrTruncated
+		}
+		n = int(binary.BigEndian.Uint16(script[pc:]))
+		pc += 2
+
+	case isPush(op):
+		n = int(op)
+
+	case op == OpArg:
+		n = 1
+
+	default:
+		return op, nil, pc, nil
+	}
+
+	if pc+n > len(script) {
+		return op, nil, pc, ErrTruncated
+	}
+	return op, script[pc : pc+n], pc + n, nil
+}
+
+// encodeNumber returns the minimal big endian encoding of n.  Zero is empty
+func encodeNumber(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+
+	i := 0
+	for i < len(b) && b[i] == 0 {
+		i++
+	}
+	return b[i:]
+}
+
+// decodeNumber decodes an unsigned big endian number of at most 8 bytes
+func decodeNumber(b []byte) (uint64, error) {
+	if len(b) > 8 {
+		return 0, ErrInvalidNumber
+	}
+
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// asBool returns true if any byte is non-zero
+func asBool(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func fromBool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{}
+}
@@ -0,0 +1,239 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// DataKey type reserved for the signer set registry
+const signerSetKeyType = "signerset"
+
+var (
+	// SignerSetKey is the DataKey of the output holding the active signer set
+	SignerSetKey = bcpb.DataKey("signerset:active")
+
+	// ErrNoSignerSet is returned when no signer set is active
+	ErrNoSignerSet = errors.New("no signer set")
+	// ErrSignerSetMismatch is returned when a block header's signers or
+	// parameters do not match the active signer set
+	ErrSignerSetMismatch = errors.New("signer set mismatch")
+
+	errReservedDataKey      = errors.New("reserved data key")
+	errSignerSetNotReplaced = errors.New("signer set not replaced")
+	errSignerSetArgMismatch = errors.New("signer set argument mismatch")
+)
+
+// NewSignerSetOutput returns the output holding the signer set.  The signers
+// are the only keys that can spend it and Q of them must sign to do so.  The
+// genesis block sets the initial signer set by including this output in a
+// base tx
+func NewSignerSetOutput(set *bcpb.SignerSet) (*bcpb.TxOutput, error) {
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := set.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	txo := &bcpb.TxOutput{
+		DataKey: SignerSetKey,
+		Data:    data,
+		PubKeys: make([]bcpb.PublicKey, len(set.Signers)),
+	}
+	copy(txo.PubKeys, set.Signers)
+	txo.SetRequiredSignatures(uint8(set.Q))
+
+	return txo, nil
+}
+
+// NewSignerSetTx returns a tx replacing the active signer set with the given
+// one.  The encoded set is added as an argument to the input so the signatures
+// cover it.  At least Q of the active signers must sign the input after which
+// the tx digest can be set
+func (bc *Blockchain) NewSignerSetTx(set *bcpb.SignerSet) (*bcpb.Tx, error) {
+	txo, err := NewSignerSetOutput(set)
+	if err != nil {
+		return nil, err
+	}
+
+	txi, err := bc.tx.NewTxInput(SignerSetKey)
+	if err != nil {
+		if err == stores.ErrDataKeyNotFound {
+			err = ErrNoSignerSet
+		}
+		return nil, err
+	}
+	txi.AddArgs(txo.Data)
+
+	tx := bcpb.NewTx()
+	tx.AddInput(txi)
+	tx.AddOutput(txo)
+
+	return tx, nil
+}
+
+// SignerSet returns the active signer set as of the last committed block
+func (bc *Blockchain) SignerSet() (*bcpb.SignerSet, error) {
+	ref, i, err := bc.tx.dki.Get(SignerSetKey)
+	if err != nil {
+		if err == stores.ErrDataKeyNotFound {
+			err = ErrNoSignerSet
+		}
+		return nil, err
+	}
+
+	return bc.getSignerSet(ref, i)
+}
+
+// SignerSetAt returns the signer set active at the given height.  A set
+// committed in a block becomes active from the next height onwards.  Only sets
+// committed on the main chain are considered.  The history is kept by the
+// BlockStorage so it is not affected by pruning
+func (bc *Blockchain) SignerSetAt(height uint32) (*bcpb.SignerSet, error) {
+	set, err := bc.blk.st.SignerSet(height)
+	if err == stores.ErrSignerSetNotFound {
+		err = ErrNoSignerSet
+	}
+	return set, err
+}
+
+// ValidateSigners is a BlockValidator that rejects headers whose signers or N,
+// S and Q parameters do not match the signer set active at the header height.
+// Headers are accepted as is while no signer set is active.  It is enabled by
+// calling SetBlockValidator
+func (bc *Blockchain) ValidateSigners(header *bcpb.BlockHeader) error {
+	set, err := bc.SignerSetAt(header.Height)
+	if err != nil {
+		if err == ErrNoSignerSet {
+			return nil
+		}
+		return err
+	}
+
+	if !set.Matches(header) {
+		return ErrSignerSetMismatch
+	}
+
+	return nil
+}
+
+func (bc *Blockchain) getSignerSet(ref bcpb.Digest, i int32) (*bcpb.SignerSet, error) {
+	tx, err := bc.tx.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	if int(i) >= len(tx.Outputs) {
+		return nil, errInvalidOutputIndex
+	}
+
+	var set bcpb.SignerSet
+	err = set.Unmarshal(tx.Outputs[i].Data)
+	return &set, err
+}
+
+// validateSignerSetTx checks the tx only writes to the signer set registry as
+// permitted against the state of the view.  See checkSignerSetTx
+func (bc *Blockchain) validateSignerSetTx(tx *bcpb.Tx, view *ledgerView) error {
+	cur, err := view.dataKey(SignerSetKey)
+	if err != nil {
+		return err
+	}
+	if cur.Deleted() {
+		return stores.ErrDataKeyDeleted
+	}
+
+	_, gen := bc.blk.st.Genesis()
+	return checkSignerSetTx(tx, cur, gen == nil)
+}
+
+// checkSignerSetTx checks the tx only writes to the signer set registry as
+// permitted given the current state of the set.  The initial set can only be
+// created by the genesis block.  After that the set can only be replaced by a
+// tx spending the active set output, which in turn requires a quorum of the
+// active signers.  A tx spending the active set must replace it.
+func checkSignerSetTx(tx *bcpb.Tx, cur stores.UndoEntry, genesis bool) error {
+	active := cur.Ref != nil
+
+	var spend *bcpb.TxInput
+	if active {
+		for _, in := range tx.Inputs {
+			if !in.IsBase() && in.Ref.Equal(cur.Ref) && in.Index == cur.Index {
+				spend = in
+				break
+			}
+		}
+	}
+
+	var replaced bool
+	for _, txo := range tx.Outputs {
+		if !bytes.Equal(txo.DataKey.Type(), []byte(signerSetKeyType)) {
+			continue
+		}
+
+		if !txo.DataKey.Equal(SignerSetKey) {
+			return errReservedDataKey
+		}
+
+		if spend == nil {
+			// Only the genesis block may create the initial set
+			if active || !genesis {
+				return errReservedDataKey
+			}
+		} else if !signerSetArg(spend, txo.Data) {
+			return errSignerSetArgMismatch
+		}
+
+		if err := validateSignerSetOutput(txo); err != nil {
+			return err
+		}
+		replaced = true
+	}
+
+	if spend != nil && !replaced {
+		return errSignerSetNotReplaced
+	}
+
+	return nil
+}
+
+// signerSetArg returns true if the input args contain the encoded set
+func signerSetArg(txi *bcpb.TxInput, data []byte) bool {
+	for _, arg := range txi.Args() {
+		if bytes.Equal(arg, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSignerSetOutput checks the output holds a valid set and can only be
+// spent by a quorum of its signers
+func validateSignerSetOutput(txo *bcpb.TxOutput) error {
+	var set bcpb.SignerSet
+	if err := set.Unmarshal(txo.Data); err != nil {
+		return err
+	}
+	if err := set.Validate(); err != nil {
+		return err
+	}
+
+	if len(txo.Logic) == 0 || txo.Logic[0] != uint8(set.Q) {
+		return bcpb.ErrInvalidSignerSet
+	}
+
+	if len(txo.PubKeys) != len(set.Signers) {
+		return bcpb.ErrInvalidSignerSet
+	}
+	for i := range set.Signers {
+		if !set.Signers[i].Equal(txo.PubKeys[i]) {
+			return bcpb.ErrInvalidSignerSet
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+)
+
+// testSignInput signs the first input of the tx with the keypairs and sets the
+// tx digest
+func testSignInput(bc *Blockchain, tx *bcpb.Tx, kps ...*keypair.KeyPair) {
+	txi := tx.Inputs[0]
+	digest := txi.Hash(bc.Hasher())
+	for _, kp := range kps {
+		sig, _ := kp.Sign(digest)
+		txi.Sign(kp.PublicKey, sig)
+	}
+	tx.SetDigest(bc.Hasher())
+}
+
+func testNextSignedBlock(t *testing.T, bc *Blockchain, set *bcpb.SignerSet, txs []*bcpb.Tx, kps ...*keypair.KeyPair) *bcpb.Block {
+	blk, err := bc.NewNextBlock(kps[0].PublicKey, set.Signers, txs, set.N, set.S, set.Q)
+	assert.Nil(t, err)
+	for _, kp := range kps {
+		sig, _ := kp.Sign(blk.Digest)
+		assert.Nil(t, blk.Sign(kp.PublicKey, sig))
+	}
+	return blk
+}
+
+func Test_Blockchain_SignerSet(t *testing.T) {
+	conf := testBlockchainConfPrefix("signerset/")
+	bc := New(conf)
+	bc.SetBlockValidator(bc.ValidateSigners)
+
+	kps := make([]*keypair.KeyPair, 4)
+	for i := range kps {
+		kps[i], _ = keypair.Generate(conf.Curve, conf.Hasher)
+	}
+
+	set1 := &bcpb.SignerSet{
+		Signers: []bcpb.PublicKey{kps[0].PublicKey, kps[1].PublicKey, kps[2].PublicKey},
+		N:       3, S: 2, Q: 2,
+	}
+	_, err := NewSignerSetOutput(&bcpb.SignerSet{Signers: set1.Signers, N: 3, S: 4, Q: 2})
+	assert.Equal(t, bcpb.ErrInvalidSignerSet, err)
+
+	_, err = bc.SignerSet()
+	assert.Equal(t, ErrNoSignerSet, err)
+
+	// Genesis sets the initial signer set
+	txo, err := NewSignerSetOutput(set1)
+	assert.Nil(t, err)
+	gtx := testBaseTx(bc, "signers:a")
+	gtx.AddOutput(txo)
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kps[3])
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	set, err := bc.SignerSet()
+	assert.Nil(t, err)
+	assert.Equal(t, set1.Signers, set.Signers)
+	_, err = bc.SignerSetAt(0)
+	assert.Equal(t, ErrNoSignerSet, err)
+	set, err = bc.SignerSetAt(1)
+	assert.Nil(t, err)
+	assert.Equal(t, set1.Q, set.Q)
+
+	// Signers must match the active set
+	blk := testSignedBlock(bc, genesis, nil, kps[3])
+	_, err = bc.Append(blk, nil)
+	assert.Equal(t, ErrSignerSetMismatch, err)
+
+	blk = testNextSignedBlock(t, bc, set1, nil, kps[0], kps[1])
+	id, err := bc.Append(blk, nil)
+	assert.Nil(t, err)
+	testCommit(t, bc, id, kps[0], kps[1])
+
+	// Registry keys cannot be written without spending the set
+	assert.Equal(t, errReservedDataKey, bc.ValidateTx(testBaseTx(bc, string(SignerSetKey))))
+	assert.Equal(t, errReservedDataKey, bc.ValidateTx(testBaseTx(bc, "signerset:other")))
+
+	set2 := &bcpb.SignerSet{
+		Signers: []bcpb.PublicKey{kps[1].PublicKey, kps[2].PublicKey, kps[3].PublicKey},
+		N:       3, S: 2, Q: 2,
+	}
+
+	// Requires a quorum of the active set
+	rtx, err := bc.NewSignerSetTx(set2)
+	assert.Nil(t, err)
+	testSignInput(bc, rtx, kps[0])
+	assert.Equal(t, errRequiresMoreSignatures, bc.ValidateTx(rtx))
+
+	// Listing a signer twice does not count twice
+	rtx, _ = bc.NewSignerSetTx(set2)
+	sig, _ := kps[0].Sign(rtx.Inputs[0].Hash(bc.Hasher()))
+	rtx.Inputs[0].PubKeys = []bcpb.PublicKey{kps[0].PublicKey, kps[0].PublicKey}
+	rtx.Inputs[0].Signatures = [][]byte{sig, sig}
+	rtx.Inputs[0].AddArgs(rtx.Outputs[0].Data)
+	sig, _ = kps[0].Sign(rtx.Inputs[0].Hash(bc.Hasher()))
+	rtx.Inputs[0].Signatures[0], rtx.Inputs[0].Signatures[1] = sig, sig
+	rtx.SetDigest(bc.Hasher())
+	assert.Equal(t, errRequiresMoreSignatures, bc.ValidateTx(rtx))
+
+	// Outputs cannot be swapped after signing
+	rtx, _ = bc.NewSignerSetTx(set2)
+	testSignInput(bc, rtx, kps[0], kps[1])
+	swapped, _ := NewSignerSetOutput(&bcpb.SignerSet{Signers: set2.Signers, N: 3, S: 1, Q: 1})
+	rtx.Outputs[0] = swapped
+	rtx.SetDigest(bc.Hasher())
+	assert.Equal(t, errSignerSetArgMismatch, bc.ValidateTx(rtx))
+
+	// Spending the set must replace it
+	rtx, _ = bc.NewSignerSetTx(set2)
+	rtx.Outputs[0] = &bcpb.TxOutput{DataKey: bcpb.DataKey("signers:b")}
+	testSignInput(bc, rtx, kps[0], kps[1])
+	assert.Equal(t, errSignerSetNotReplaced, bc.ValidateTx(rtx))
+
+	// Rotate
+	rtx, _ = bc.NewSignerSetTx(set2)
+	testSignInput(bc, rtx, kps[0], kps[1])
+	assert.Nil(t, bc.ValidateTx(rtx))
+
+	blk = testNextSignedBlock(t, bc, set1, []*bcpb.Tx{rtx}, kps[0], kps[2])
+	id, err = bc.Append(blk, []*bcpb.Tx{rtx})
+	assert.Nil(t, err)
+	testCommit(t, bc, id, kps[1], kps[2])
+
+	set, _ = bc.SignerSet()
+	assert.Equal(t, set2.Signers, set.Signers)
+	set, _ = bc.SignerSetAt(2)
+	assert.Equal(t, set1.Signers, set.Signers)
+	set, _ = bc.SignerSetAt(3)
+	assert.Equal(t, set2.Signers, set.Signers)
+	set, _ = bc.SignerSetAt(100)
+	assert.Equal(t, set2.Signers, set.Signers)
+
+	// The history is not kept as DataKeys
+	var keys []string
+	bc.tx.dki.Iter(bcpb.DataKey(signerSetKeyType), func(key bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, []string{string(SignerSetKey)}, keys)
+
+	// Old set can no longer sign
+	blk = testNextSignedBlock(t, bc, set1, nil, kps[0], kps[1])
+	_, err = bc.Append(blk, nil)
+	assert.Equal(t, ErrSignerSetMismatch, err)
+
+	blk = testNextSignedBlock(t, bc, set2, nil, kps[2], kps[3])
+	id, err = bc.Append(blk, nil)
+	assert.Nil(t, err)
+	testCommit(t, bc, id, kps[2], kps[3])
+
+	// Rewinding past the rotation restores the previous set
+	assert.Nil(t, bc.Rewind(1))
+	set, _ = bc.SignerSet()
+	assert.Equal(t, set1.Signers, set.Signers)
+	set, _ = bc.SignerSetAt(3)
+	assert.Equal(t, set1.Signers, set.Signers)
+}
@@ -0,0 +1,279 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+var (
+	// ErrCheckpointMismatch is returned when restoring a snapshot that was not
+	// taken at the trusted checkpoint block
+	ErrCheckpointMismatch = errors.New("snapshot does not match checkpoint")
+	// ErrStoreNotEmpty is returned when restoring a snapshot to a ledger that
+	// already has a genesis block
+	ErrStoreNotEmpty = errors.New("store not empty")
+
+	errInvalidSnapshot = errors.New("invalid snapshot")
+)
+
+// Snapshot returns the state of every DataKey, including deleted ones, and the
+// unspent outputs as of the last committed block.  The txs of the block are
+// included so it can be exported and its txs looked up once restored.  The
+// signers of the block must sign it, see bcpb.Snapshot.Sign, before it can be
+// restored
+func (bc *Blockchain) Snapshot() (*bcpb.Snapshot, error) {
+	lid, last := bc.blk.st.Last()
+	if last == nil {
+		return nil, stores.ErrBlockNotFound
+	}
+	last.Digest = lid
+
+	snap := &bcpb.Snapshot{
+		Block:   last,
+		Entries: make([]bcpb.SnapshotEntry, 0),
+		Unspent: make([]bcpb.SnapshotOutput, 0),
+		Txs:     make([]*bcpb.Tx, 0),
+	}
+
+	err := bc.tx.dki.IterAll(nil, func(key bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		snap.Entries = append(snap.Entries, bcpb.SnapshotEntry{
+			Key:   append(bcpb.DataKey(nil), key...),
+			Ref:   ref.Copy(),
+			Index: i,
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = bc.tx.utxo.Iter(func(ref bcpb.Digest, i int32) bool {
+		snap.Unspent = append(snap.Unspent, bcpb.SnapshotOutput{Ref: ref, Index: i})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]bcpb.Digest, 0, len(snap.Entries)+len(snap.Unspent)+len(last.Txs))
+	for _, e := range snap.Entries {
+		// The tx deleting a DataKey is not needed to restore its tombstone
+		if e.Index != stores.TombstoneIndex {
+			refs = append(refs, e.Ref)
+		}
+	}
+	for _, o := range snap.Unspent {
+		refs = append(refs, o.Ref)
+	}
+	refs = append(refs, last.Txs...)
+
+	seen := make(map[string]struct{})
+	for _, ref := range refs {
+		if _, ok := seen[ref.String()]; ok {
+			continue
+		}
+		seen[ref.String()] = struct{}{}
+
+		tx, err := bc.tx.Get(ref)
+		if err != nil {
+			return nil, err
+		}
+		snap.Txs = append(snap.Txs, tx)
+	}
+
+	return snap, nil
+}
+
+// Restore bootstraps an empty ledger from the snapshot.  The snapshot block
+// must be the trusted checkpoint and both the block and the snapshot must carry
+// at least S valid signatures of the block signers.  The snapshot block becomes
+// the genesis and last block of the ledger and blocks extending it can then be
+// appended and committed as usual.  Every unspent output and deleted DataKey in
+// the snapshot is restored and the txs of the block are located in it.  History
+// prior to the snapshot, including that of the signer set, is not available and
+// the ledger cannot be rewound past it.  The state is written in chunks with
+// the snapshot block set last, so if the restore fails it can be run again.
+func (bc *Blockchain) Restore(snap *bcpb.Snapshot, checkpoint bcpb.Digest) error {
+	if _, gen := bc.blk.st.Genesis(); gen != nil {
+		return ErrStoreNotEmpty
+	}
+
+	blk := snap.Block
+	if blk == nil || blk.Header == nil {
+		return errInvalidSnapshot
+	}
+	if !blk.Digest.Equal(checkpoint) || !blk.Header.Hash(bc.h).Equal(checkpoint) {
+		return ErrCheckpointMismatch
+	}
+
+	weight, ok := bc.verifyBlockSignatures(blk)
+	if !ok {
+		return bcpb.ErrSignatureVerificationFailed
+	}
+
+	header := blk.Header
+	sc := keypair.CountSignatures(bc.curve, bc.h, snap.Digest(bc.h), header.Signers, snap.Signatures)
+	if sc < header.S {
+		return bcpb.ErrSignatureVerificationFailed
+	}
+
+	txs := make(map[string]*bcpb.Tx, len(snap.Txs))
+	for _, tx := range snap.Txs {
+		if !bc.validTxDigest(tx) {
+			return errInvalidSnapshot
+		}
+		txs[tx.Digest.String()] = tx
+	}
+
+	for _, tid := range blk.Txs {
+		if _, ok := txs[tid.String()]; !ok {
+			return errInvalidSnapshot
+		}
+	}
+
+	// output returns the output the snapshot references
+	output := func(ref bcpb.Digest, i int32) (*bcpb.TxOutput, error) {
+		tx, ok := txs[ref.String()]
+		if !ok || i < 0 || int(i) >= len(tx.Outputs) {
+			return nil, errInvalidSnapshot
+		}
+		return tx.Outputs[i], nil
+	}
+
+	// Check all references before writing anything
+	var active *bcpb.SignerSet
+	for _, e := range snap.Entries {
+		if e.Index == stores.TombstoneIndex {
+			continue
+		}
+		txo, err := output(e.Ref, e.Index)
+		if err != nil {
+			return err
+		}
+
+		// The signer set history starts with the set active after the block
+		if e.Key.Equal(SignerSetKey) {
+			active = &bcpb.SignerSet{}
+			if err = active.Unmarshal(txo.Data); err != nil {
+				return errInvalidSnapshot
+			}
+		}
+	}
+	for _, o := range snap.Unspent {
+		if _, err := output(o.Ref, o.Index); err != nil {
+			return err
+		}
+	}
+
+	cb := bc.newChunkedBatch()
+	defer cb.discard()
+
+	for _, tx := range snap.Txs {
+		if err := bc.tx.tx.Set(cb.batch, tx); err != nil {
+			return err
+		}
+		if err := cb.step(); err != nil {
+			return err
+		}
+	}
+	for i, tid := range blk.Txs {
+		loc := stores.TxLocation{Block: checkpoint, Height: header.Height, Index: int32(i)}
+		if err := bc.tx.tx.SetLocation(cb.batch, tid, loc); err != nil {
+			return err
+		}
+		if err := cb.step(); err != nil {
+			return err
+		}
+	}
+
+	for i, e := range snap.Entries {
+		var txo *bcpb.TxOutput
+		if e.Index != stores.TombstoneIndex {
+			txo, _ = output(e.Ref, e.Index)
+		}
+		if err := bc.restoreEntry(cb.batch, header, e, uint32(i), txo); err != nil {
+			return err
+		}
+		if err := cb.step(); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range snap.Unspent {
+		txo, _ := output(o.Ref, o.Index)
+		if err := bc.tx.utxo.Add(cb.batch, o.Ref, o.Index, txo); err != nil {
+			return err
+		}
+		if err := cb.step(); err != nil {
+			return err
+		}
+	}
+
+	// The snapshot block is set last so an incomplete restore leaves the ledger
+	// empty
+	if err := cb.commit(); err != nil {
+		return err
+	}
+
+	batch := bc.batcher.NewBatch()
+	defer batch.Discard()
+
+	if active != nil {
+		if err := bc.blk.st.SetSignerSet(batch, header.Height+1, active); err != nil {
+			return err
+		}
+	}
+	if err := bc.blk.SetGenesis(batch, blk, weight); err != nil {
+		return err
+	}
+	if err := bc.blk.st.SetLast(batch, checkpoint); err != nil {
+		return err
+	}
+	if err := bc.blk.st.SetLastExec(batch, checkpoint); err != nil {
+		return err
+	}
+	if err := bc.blk.st.SetHeight(batch, header.Height, checkpoint); err != nil {
+		return err
+	}
+
+	return batch.Commit()
+}
+
+// restoreEntry sets the DataKey state along with the history and, unless it is
+// deleted, the query index of its output
+func (bc *Blockchain) restoreEntry(batch stores.Batch, header *bcpb.BlockHeader, e bcpb.SnapshotEntry, seq uint32, txo *bcpb.TxOutput) error {
+	var err error
+	if e.Index == stores.TombstoneIndex {
+		err = bc.tx.dki.Delete(batch, e.Key, e.Ref)
+	} else {
+		err = bc.tx.dki.Set(batch, e.Key, e.Ref, e.Index)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = bc.tx.dki.AddHistory(batch, e.Key, stores.HistoryEntry{
+		Height:    header.Height,
+		Timestamp: header.Timestamp,
+		Seq:       seq,
+		Ref:       e.Ref,
+		Index:     e.Index,
+	})
+	if err != nil || txo == nil {
+		return err
+	}
+
+	return bc.tx.qi.Add(batch, e.Key, txo)
+}
+
+// validTxDigest returns true if the tx digest matches its contents
+func (bc *Blockchain) validTxDigest(tx *bcpb.Tx) bool {
+	if tx.Header == nil {
+		return false
+	}
+	data := tx.DataHash(bc.h)
+	return data.Equal(tx.Header.Data) && tx.Header.Hash(bc.h).Equal(tx.Digest)
+}
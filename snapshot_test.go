@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func Test_Blockchain_Snapshot(t *testing.T) {
+	conf := testBlockchainConfPrefix("snapshot/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+	signers := []bcpb.PublicKey{kp.PublicKey}
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "snap:a", "snap:b", "snap:c")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// The first output is unspent but no longer referenced by its DataKey
+	atx := testUpdateTx(t, bc, "snap:a", "1")
+	atx.Outputs = append([]*bcpb.TxOutput{{DataKey: bcpb.DataKey("snap:a"), Data: []byte("0")}}, atx.Outputs...)
+	atx.SetDigest(bc.Hasher())
+
+	// snap:c is deleted
+	txi, err := bc.NewTxInput(bcpb.DataKey("snap:c"))
+	assert.Nil(t, err)
+	dtx := bcpb.NewTx()
+	dtx.AddInput(txi)
+	dtx.SetDigest(bc.Hasher())
+
+	atxs := []*bcpb.Tx{atx, dtx}
+	a1, err := bc.NewNextBlock(kp.PublicKey, signers, atxs, 1, 1, 0)
+	assert.Nil(t, err)
+	sig, _ := kp.Sign(a1.Digest)
+	assert.Nil(t, a1.Sign(kp.PublicKey, sig))
+	_, err = bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a1.Digest))
+
+	snap, err := bc.Snapshot()
+	assert.Nil(t, err)
+	assert.Equal(t, a1.Digest, snap.Block.Digest)
+	assert.Equal(t, 3, len(snap.Entries))
+	assert.Equal(t, 3, len(snap.Unspent))
+	assert.Equal(t, 3, len(snap.Txs))
+
+	// Restore into a fresh ledger
+	conf2 := testBlockchainConfPrefix("restore/")
+	bc2 := New(conf2)
+
+	assert.Equal(t, ErrCheckpointMismatch, bc2.Restore(snap, genesis.Digest))
+	assert.Equal(t, bcpb.ErrSignatureVerificationFailed, bc2.Restore(snap, a1.Digest))
+
+	sig, _ = kp.Sign(snap.Digest(bc.Hasher()))
+	assert.Nil(t, snap.Sign(kp.PublicKey, sig))
+
+	b, err := snap.Marshal()
+	assert.Nil(t, err)
+	var decoded bcpb.Snapshot
+	assert.Nil(t, decoded.Unmarshal(b))
+
+	// Tampered state is rejected
+	tampered := decoded
+	tampered.Entries = append([]bcpb.SnapshotEntry{}, decoded.Entries...)
+	tampered.Entries[0].Index = 5
+	assert.Equal(t, bcpb.ErrSignatureVerificationFailed, bc2.Restore(&tampered, a1.Digest))
+
+	assert.Nil(t, bc2.Restore(&decoded, a1.Digest))
+	assert.Equal(t, ErrStoreNotEmpty, bc2.Restore(&decoded, a1.Digest))
+	assert.Equal(t, a1.Digest, bc2.Last().Digest)
+
+	out, err := bc2.GetTXOByDataKey(bcpb.DataKey("snap:a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), out.Data)
+
+	// Deleted keys stay deleted
+	_, _, err = bc2.tx.dki.Get(bcpb.DataKey("snap:c"))
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+
+	// Every unspent output and the txs of the checkpoint block are restored
+	orphan := bcpb.NewTx()
+	orphan.AddInput(bcpb.NewTxInput(atx.Digest, 0, nil))
+	orphan.AddOutput(&bcpb.TxOutput{})
+	orphan.SetDigest(bc.Hasher())
+	assert.Nil(t, bc.ValidateTx(orphan))
+	assert.Nil(t, bc2.ValidateTx(orphan))
+
+	status, err := bc2.TxStatus(atx.Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxCommitted, status.State)
+
+	// Other restored txs are known but their blocks are not
+	status, err = bc2.TxStatus(gtxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxPending, status.State)
+
+	// Continue from the snapshot height
+	btxs := []*bcpb.Tx{testUpdateTx(t, bc2, "snap:b", "2")}
+	a2, err := bc2.NewNextBlock(kp.PublicKey, signers, btxs, 1, 1, 0)
+	assert.Nil(t, err)
+	sig, _ = kp.Sign(a2.Digest)
+	assert.Nil(t, a2.Sign(kp.PublicKey, sig))
+	_, err = bc2.Append(a2, btxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc2.Commit(a2.Digest))
+
+	out, err = bc2.GetTXOByDataKey(bcpb.DataKey("snap:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), out.Data)
+
+	// The main chain starts at the snapshot block
+	assert.Nil(t, bc2.ReindexHeights())
+	blocks, err := bc2.BlocksByHeight(a1.Height(), 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(blocks))
+	blocks, err = bc2.BlocksByHeight(0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(blocks))
+
+	// The same block applies to the original ledger
+	_, err = bc.Append(a2, btxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(a2.Digest))
+
+	// The export of a restored ledger starts at the snapshot block
+	var buf bytes.Buffer
+	assert.Nil(t, bc2.Export(&buf))
+	stream := buf.Bytes()
+
+	bc3 := New(testBlockchainConfPrefix("restore-import/"))
+	err = bc3.Import(bytes.NewReader(stream), nil)
+	assert.Equal(t, ErrImportSnapshot, err.(*ImportError).Err)
+	assert.Equal(t, a1.Height(), err.(*ImportError).Height)
+
+	assert.Nil(t, bc3.Restore(&decoded, a1.Digest))
+	heights := make([]uint32, 0)
+	err = bc3.Import(bytes.NewReader(stream), func(h uint32) {
+		heights = append(heights, h)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{a2.Height()}, heights)
+	assert.Equal(t, a2.Digest, bc3.Last().Digest)
+
+	out, err = bc3.GetTXOByDataKey(bcpb.DataKey("snap:b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), out.Data)
+	assert.Equal(t, ErrStoreNotEmpty, bc3.Import(bytes.NewReader(stream), nil))
+}
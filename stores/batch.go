@@ -0,0 +1,81 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/dgraph-io/badger"
+)
+
+// ErrBatchType is returned when a store is given a batch that was not created
+// for the database backing the store
+var ErrBatchType = errors.New("batch type mismatch")
+
+// Batch is a write transaction that spans all stores backed by the same
+// database.  Writes made as part of a batch only become visible once Commit
+// succeeds and are all dropped on Discard.
+type Batch interface {
+	// Commit atomically applies all writes in the batch
+	Commit() error
+	// Discard drops all writes in the batch.  It is safe to call after Commit
+	Discard()
+}
+
+// BadgerBatcher creates batches backed by a single badger read-write
+// transaction.  All stores the batches are used with must share the same db
+type BadgerBatcher struct {
+	db *badger.DB
+}
+
+// NewBadgerBatcher inits a new BadgerBatcher for the given db
+func NewBadgerBatcher(db *badger.DB) *BadgerBatcher {
+	return &BadgerBatcher{db: db}
+}
+
+// NewBatch starts a new write batch
+func (b *BadgerBatcher) NewBatch() Batch {
+	return &badgerBatch{db: b.db, txn: b.db.NewTransaction(true)}
+}
+
+type badgerBatch struct {
+	db  *badger.DB
+	txn *badger.Txn
+}
+
+func (b *badgerBatch) Commit() error {
+	return b.txn.Commit(nil)
+}
+
+func (b *badgerBatch) Discard() {
+	b.txn.Discard()
+}
+
+// badgerUpdate calls f with the transaction of the batch if one is given,
+// otherwise f is run in its own read-write transaction
+func badgerUpdate(db *badger.DB, batch Batch, f func(*badger.Txn) error) error {
+	if batch == nil {
+		return db.Update(f)
+	}
+
+	bb, ok := batch.(*badgerBatch)
+	if !ok || bb.db != db {
+		return ErrBatchType
+	}
+
+	return f(bb.txn)
+}
+
+// concatKey returns a newly allocated key made up of the given parts.  Keys
+// handed to a batch are held until it is committed so they must never share
+// a backing array
+func concatKey(parts ...[]byte) []byte {
+	var l int
+	for _, p := range parts {
+		l += len(p)
+	}
+
+	key := make([]byte, 0, l)
+	for _, p := range parts {
+		key = append(key, p...)
+	}
+	return key
+}
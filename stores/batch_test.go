@@ -0,0 +1,80 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+func Test_BadgerBatch(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "batch-")
+	defer os.RemoveAll(tmpdir)
+
+	db, err := testBadgerDB(tmpdir)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	h := hasher.Default()
+	batcher := NewBadgerBatcher(db)
+	bst := NewBadgerBlockStorage(db, []byte("batch/"), h)
+	tst := NewBadgerTxStorage(db, []byte("batch/"))
+	idx := NewBadgerDataKeyIndex(db, []byte("batch/"))
+
+	tx := bcpb.NewBaseTx()
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("batch:key")})
+	tx.SetDigest(h)
+
+	blk := bcpb.NewBlock()
+	blk.SetTxs([]*bcpb.Tx{tx}, h)
+	blk.SetHash(h)
+
+	write := func(batch Batch) {
+		assert.Nil(t, tst.Set(batch, tx))
+		id, err := bst.Add(batch, blk)
+		assert.Nil(t, err)
+		assert.Nil(t, bst.SetLast(batch, id))
+		assert.Nil(t, idx.Set(batch, bcpb.DataKey("batch:key"), tx.Digest, 0))
+	}
+
+	// Discarded writes are never visible
+	batch := batcher.NewBatch()
+	write(batch)
+	batch.Discard()
+
+	assert.False(t, bst.Exists(blk.Digest))
+	_, err = tst.Get(tx.Digest)
+	assert.NotNil(t, err)
+	_, _, err = idx.Get(bcpb.DataKey("batch:key"))
+	assert.NotNil(t, err)
+
+	// Committed writes are visible across all stores
+	batch = batcher.NewBatch()
+	write(batch)
+	assert.Nil(t, batch.Commit())
+	batch.Discard()
+
+	assert.True(t, bst.Exists(blk.Digest))
+	lid, _ := bst.Last()
+	assert.Equal(t, blk.Digest, lid)
+	_, err = tst.Get(tx.Digest)
+	assert.Nil(t, err)
+	ref, _, err := idx.Get(bcpb.DataKey("batch:key"))
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Digest, ref)
+
+	// Batches from another db are rejected
+	tmpdir2, _ := ioutil.TempDir("/tmp", "batch-")
+	defer os.RemoveAll(tmpdir2)
+	db2, err := testBadgerDB(tmpdir2)
+	assert.Nil(t, err)
+	defer db2.Close()
+
+	batch = NewBadgerBatcher(db2).NewBatch()
+	defer batch.Discard()
+	assert.Equal(t, ErrBatchType, tst.Set(batch, tx))
+}
@@ -2,6 +2,7 @@ package stores
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 
 	"github.com/dgraph-io/badger"
@@ -20,6 +21,16 @@ const (
 	blkLastSubkeyPrefix = "last"
 	// Last executed block key sub prefix appended to blkSubkeyPrefix
 	blkExecSubkeyPrefix = "exec"
+	// Branch head key sub prefix appended to blkSubkeyPrefix
+	blkTipSubkeyPrefix = "tip/"
+	// Cumulative branch weight key sub prefix appended to blkSubkeyPrefix
+	blkWeightSubkeyPrefix = "weight/"
+	// Commit certificate key sub prefix appended to blkSubkeyPrefix
+	blkCertSubkeyPrefix = "cert/"
+	// Main chain height key sub prefix appended to blkSubkeyPrefix
+	blkHeightSubkeyPrefix = "height/"
+	// Signer set activation height key sub prefix appended to blkSubkeyPrefix
+	blkSignerSetSubkeyPrefix = "signerset/"
 )
 
 var (
@@ -27,11 +38,52 @@ var (
 	ErrBlockExists = errors.New("block exists")
 	// ErrBlockNotFound is used when a request block isn't found
 	ErrBlockNotFound = errors.New("block not found")
+	// ErrCertificateNotFound is used when a block has no commit certificate
+	ErrCertificateNotFound = errors.New("certificate not found")
+	// ErrSignerSetNotFound is used when no signer set is active at a height
+	ErrSignerSetNotFound = errors.New("signer set not found")
 )
 
 // BlockIterator is used to iterate over blocks in a store
 type BlockIterator func(bcpb.Digest, *bcpb.Block) error
 
+// heightKey returns the big endian encoding of the height
+func heightKey(height uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, height)
+	return b
+}
+
+// iterHeights calls f with the main chain block at each height from start up,
+// or down if reversed, returned by get.  Iteration ends at the first height
+// without a block, after limit blocks if limit is greater than zero or once f
+// returns an error
+func iterHeights(start uint32, limit int, reverse bool, get func(uint32) (*bcpb.Block, error), f BlockIterator) error {
+	for h, c := start, 0; limit <= 0 || c < limit; c++ {
+		blk, err := get(h)
+		if err == ErrBlockNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = f(blk.Digest, blk); err != nil {
+			return err
+		}
+
+		if reverse {
+			if h == 0 {
+				return nil
+			}
+			h--
+		} else {
+			h++
+		}
+	}
+
+	return nil
+}
+
 type BadgerBlockStorage struct {
 	hasher hasher.Hasher
 
@@ -45,11 +97,12 @@ func NewBadgerBlockStorage(db *badger.DB, keyPrefix []byte, h hasher.Hasher) *Ba
 	return &BadgerBlockStorage{
 		db:     db,
 		hasher: h,
-		prefix: append(keyPrefix, []byte(blkSubkeyPrefix)...),
+		prefix: concatKey(keyPrefix, []byte(blkSubkeyPrefix)),
 	}
 
 }
 
+// Get returns the block by the given id or ErrBlockNotFound
 func (st *BadgerBlockStorage) Get(id bcpb.Digest) (*bcpb.Block, error) {
 	key := st.getkey(id)
 
@@ -57,6 +110,9 @@ func (st *BadgerBlockStorage) Get(id bcpb.Digest) (*bcpb.Block, error) {
 	err := st.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = ErrBlockNotFound
+			}
 			return err
 		}
 		data, err = item.ValueCopy(nil)
@@ -109,7 +165,7 @@ func (st *BadgerBlockStorage) Exists(id bcpb.Digest) bool {
 	return (err == nil)
 }
 
-func (st *BadgerBlockStorage) Add(b *bcpb.Block) (bcpb.Digest, error) {
+func (st *BadgerBlockStorage) Add(batch Batch, b *bcpb.Block) (bcpb.Digest, error) {
 	id := b.Header.Hash(st.hasher.Clone())
 	key := st.getkey(id)
 
@@ -118,7 +174,7 @@ func (st *BadgerBlockStorage) Add(b *bcpb.Block) (bcpb.Digest, error) {
 		return nil, err
 	}
 
-	err = st.db.Update(func(txn *badger.Txn) error {
+	err = badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
 		_, er := txn.Get(key)
 		if er == nil {
 			return ErrBlockExists
@@ -130,33 +186,161 @@ func (st *BadgerBlockStorage) Add(b *bcpb.Block) (bcpb.Digest, error) {
 	return id, err
 }
 
-func (st *BadgerBlockStorage) SetGenesis(id bcpb.Digest) error {
-	return st.db.Update(func(txn *badger.Txn) error {
+func (st *BadgerBlockStorage) SetGenesis(batch Batch, id bcpb.Digest) error {
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
 		return txn.Set(st.getkey([]byte(blkGenesisSubkeyPrefix)), id)
 	})
 }
 
-func (st *BadgerBlockStorage) SetLast(id bcpb.Digest) error {
-	return st.db.Update(func(txn *badger.Txn) error {
+func (st *BadgerBlockStorage) SetLast(batch Batch, id bcpb.Digest) error {
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
 		return txn.Set(st.getkey([]byte(blkLastSubkeyPrefix)), id)
 	})
 }
 
 // SetLastExec checks if the given digest exists and marks it as the last
 // executed block
-func (st *BadgerBlockStorage) SetLastExec(id bcpb.Digest) error {
-	return st.db.Update(func(txn *badger.Txn) error {
+func (st *BadgerBlockStorage) SetLastExec(batch Batch, id bcpb.Digest) error {
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
 		_, err := txn.Get(st.getkey(id))
 		if err == nil {
 			err = txn.Set(st.getkey([]byte(blkExecSubkeyPrefix)), id)
+		} else if err == badger.ErrKeyNotFound {
+			err = ErrBlockNotFound
 		}
 		return err
 	})
 }
 
-func (st *BadgerBlockStorage) Remove(id bcpb.Digest) error {
-	key := st.getkey(id)
-	return st.db.Update(func(txn *badger.Txn) error {
+// Remove removes the block along with its weight, tip entry and commit
+// certificate
+func (st *BadgerBlockStorage) Remove(batch Batch, id bcpb.Digest) error {
+	keys := [][]byte{
+		st.getkey(id),
+		concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id),
+		concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id),
+		concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id),
+	}
+
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Weight returns the cumulative signature weight of the branch ending at the
+// given block
+func (st *BadgerBlockStorage) Weight(id bcpb.Digest) (uint64, error) {
+	key := concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id)
+
+	var weight uint64
+	err := st.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = ErrBlockNotFound
+			}
+			return err
+		}
+		val, err := item.Value()
+		if err == nil {
+			weight = binary.BigEndian.Uint64(val)
+		}
+		return err
+	})
+
+	return weight, err
+}
+
+// SetWeight sets the cumulative signature weight of the branch ending at the
+// given block
+func (st *BadgerBlockStorage) SetWeight(batch Batch, id bcpb.Digest, weight uint64) error {
+	key := concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id)
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, weight)
+
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, val)
+	})
+}
+
+// Certificate returns the commit certificate of the block
+func (st *BadgerBlockStorage) Certificate(id bcpb.Digest) (*bcpb.CommitCertificate, error) {
+	key := concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id)
+
+	var data []byte
+	err := st.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			err = ErrCertificateNotFound
+		}
+		return nil, err
+	}
+
+	var cert bcpb.CommitCertificate
+	err = proto.Unmarshal(data, &cert)
+	return &cert, err
+}
+
+// SetCertificate sets the commit certificate of the block
+func (st *BadgerBlockStorage) SetCertificate(batch Batch, id bcpb.Digest, cert *bcpb.CommitCertificate) error {
+	key := concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id)
+	val, err := proto.Marshal(cert)
+	if err != nil {
+		return err
+	}
+
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, val)
+	})
+}
+
+// Tips returns the digests of all blocks marked as branch heads in key order
+func (st *BadgerBlockStorage) Tips() []bcpb.Digest {
+	prefix := concatKey(st.prefix, []byte(blkTipSubkeyPrefix))
+	tips := make([]bcpb.Digest, 0)
+
+	st.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.Valid(); iter.Next() {
+			key := iter.Item().Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+			tips = append(tips, bcpb.Digest(bytes.TrimPrefix(key, prefix)).Copy())
+		}
+		return nil
+	})
+
+	return tips
+}
+
+// SetTip marks the block as a branch head
+func (st *BadgerBlockStorage) SetTip(batch Batch, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id)
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, nil)
+	})
+}
+
+// RemoveTip unmarks the block as a branch head
+func (st *BadgerBlockStorage) RemoveTip(batch Batch, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id)
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
 		return txn.Delete(key)
 	})
 }
@@ -196,12 +380,142 @@ func (st *BadgerBlockStorage) Iter(f BlockIterator) error {
 	})
 }
 
+// GetByHeight returns the main chain block at the height with its Digest set
+// or ErrBlockNotFound
+func (st *BadgerBlockStorage) GetByHeight(height uint32) (*bcpb.Block, error) {
+	var blk *bcpb.Block
+	err := st.db.View(func(txn *badger.Txn) error {
+		var err error
+		blk, err = st.getHeightBlock(txn, height)
+		return err
+	})
+	return blk, err
+}
+
+// IterHeight iterates over the main chain blocks by height starting at the
+// given height in ascending or, if reverse, descending order.  A limit of zero
+// or less iterates over all of them.  The Digest of each block is set
+func (st *BadgerBlockStorage) IterHeight(start uint32, limit int, reverse bool, f BlockIterator) error {
+	return st.db.View(func(txn *badger.Txn) error {
+		return iterHeights(start, limit, reverse, func(h uint32) (*bcpb.Block, error) {
+			return st.getHeightBlock(txn, h)
+		}, f)
+	})
+}
+
+// SetHeight sets the main chain block at the height
+func (st *BadgerBlockStorage) SetHeight(batch Batch, height uint32, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height))
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, id)
+	})
+}
+
+// RemoveHeight removes the main chain block at the height
+func (st *BadgerBlockStorage) RemoveHeight(batch Batch, height uint32) error {
+	key := concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height))
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// SignerSet returns the signer set activated at the highest height not above
+// the given one or ErrSignerSetNotFound
+func (st *BadgerBlockStorage) SignerSet(height uint32) (*bcpb.SignerSet, error) {
+	prefix := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix))
+	last := heightKey(height)
+
+	var data []byte
+	err := st.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.Valid(); iter.Next() {
+			item := iter.Item()
+			key := item.Key()
+			if !bytes.HasPrefix(key, prefix) || bytes.Compare(key[len(prefix):], last) > 0 {
+				break
+			}
+
+			var err error
+			if data, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, ErrSignerSetNotFound
+	}
+
+	var set bcpb.SignerSet
+	err = proto.Unmarshal(data, &set)
+	return &set, err
+}
+
+// SetSignerSet sets the signer set activated at the height
+func (st *BadgerBlockStorage) SetSignerSet(batch Batch, height uint32, set *bcpb.SignerSet) error {
+	key := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix), heightKey(height))
+	val, err := proto.Marshal(set)
+	if err != nil {
+		return err
+	}
+
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, val)
+	})
+}
+
+// RemoveSignerSet removes the signer set activated at the height
+func (st *BadgerBlockStorage) RemoveSignerSet(batch Batch, height uint32) error {
+	key := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix), heightKey(height))
+	return badgerUpdate(st.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+func (st *BadgerBlockStorage) getHeightBlock(txn *badger.Txn, height uint32) (*bcpb.Block, error) {
+	item, err := txn.Get(concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height)))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			err = ErrBlockNotFound
+		}
+		return nil, err
+	}
+	id, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err = txn.Get(st.getkey(id))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			err = ErrBlockNotFound
+		}
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var blk bcpb.Block
+	if err = proto.Unmarshal(val, &blk); err != nil {
+		return nil, err
+	}
+	blk.Digest = bcpb.Digest(id)
+	return &blk, nil
+}
+
 func (st *BadgerBlockStorage) Close() error {
 	return st.db.Close()
 }
 
 func (st *BadgerBlockStorage) getkey(key []byte) []byte {
-	return append(st.prefix, key...)
+	return concatKey(st.prefix, key)
 }
 
 func (st *BadgerBlockStorage) getGenesisBlock(txn *badger.Txn) (bcpb.Digest, *bcpb.Block, error) {
@@ -0,0 +1,90 @@
+package stores_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain"
+	"github.com/hexablock/blockchain/stores"
+	"github.com/hexablock/blockchain/stores/storetest"
+	"github.com/hexablock/hasher"
+)
+
+func Test_Badger_Conformance(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "conformance-")
+	defer os.RemoveAll(tmpdir)
+
+	opt := badger.DefaultOptions
+	opt.Dir = tmpdir
+	opt.ValueDir = tmpdir
+	db, err := badger.Open(opt)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	// Each store gets its own prefix so it starts empty
+	var n int
+	prefix := func() []byte {
+		n++
+		return []byte(fmt.Sprintf("conf%d/", n))
+	}
+
+	storetest.TestBlockStorage(t, func() blockchain.BlockStorage {
+		return stores.NewBadgerBlockStorage(db, prefix(), hasher.Default())
+	})
+	storetest.TestTxStorage(t, func() blockchain.TxStorage {
+		return stores.NewBadgerTxStorage(db, prefix())
+	})
+	storetest.TestDataKeyIndex(t, func() blockchain.DataKeyIndex {
+		return stores.NewBadgerDataKeyIndex(db, prefix())
+	})
+	storetest.TestUTXOIndex(t, func() blockchain.UTXOIndex {
+		return stores.NewBadgerUTXOIndex(db, prefix(), hasher.Default())
+	})
+	storetest.TestQueryIndex(t, func() blockchain.QueryIndex {
+		return stores.NewBadgerQueryIndex(db, prefix())
+	})
+	storetest.TestBatch(t, func() storetest.Stores {
+		return storetest.Stores{
+			Batcher: stores.NewBadgerBatcher(db),
+			Block:   stores.NewBadgerBlockStorage(db, prefix(), hasher.Default()),
+			Tx:      stores.NewBadgerTxStorage(db, prefix()),
+			DataKey: stores.NewBadgerDataKeyIndex(db, prefix()),
+			UTXO:    stores.NewBadgerUTXOIndex(db, prefix(), hasher.Default()),
+			Query:   stores.NewBadgerQueryIndex(db, prefix()),
+		}
+	})
+}
+
+func Test_Mem_Conformance(t *testing.T) {
+	storetest.TestBlockStorage(t, func() blockchain.BlockStorage {
+		return stores.NewMemBlockStorage(stores.NewMemDB(), nil, hasher.Default())
+	})
+	storetest.TestTxStorage(t, func() blockchain.TxStorage {
+		return stores.NewMemTxStorage(stores.NewMemDB(), nil)
+	})
+	storetest.TestDataKeyIndex(t, func() blockchain.DataKeyIndex {
+		return stores.NewMemDataKeyIndex(stores.NewMemDB(), nil)
+	})
+	storetest.TestUTXOIndex(t, func() blockchain.UTXOIndex {
+		return stores.NewMemUTXOIndex(stores.NewMemDB(), nil, hasher.Default())
+	})
+	storetest.TestQueryIndex(t, func() blockchain.QueryIndex {
+		return stores.NewMemQueryIndex(stores.NewMemDB(), nil)
+	})
+	storetest.TestBatch(t, func() storetest.Stores {
+		db := stores.NewMemDB()
+		return storetest.Stores{
+			Batcher: stores.NewMemBatcher(db),
+			Block:   stores.NewMemBlockStorage(db, []byte("blk/"), hasher.Default()),
+			Tx:      stores.NewMemTxStorage(db, []byte("tx/")),
+			DataKey: stores.NewMemDataKeyIndex(db, []byte("dk/")),
+			UTXO:    stores.NewMemUTXOIndex(db, []byte("utxo/"), hasher.Default()),
+			Query:   stores.NewMemQueryIndex(db, []byte("query/")),
+		}
+	})
+}
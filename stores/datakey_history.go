@@ -0,0 +1,156 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+const (
+	// DataKey history key sub prefix
+	histSubkeyPrefix = "hist/"
+)
+
+var errInvalidHistoryEntry = errors.New("invalid history entry")
+
+// HistoryEntry is a state a DataKey was set to by a block on the main chain.
+// A deleted DataKey has the tombstone state i.e. Index is TombstoneIndex and
+// Ref the deleting tx
+type HistoryEntry struct {
+	// Height and timestamp of the block
+	Height    uint32
+	Timestamp int64
+	// Order of the write within the block
+	Seq uint32
+
+	Ref   bcpb.Digest
+	Index int32
+}
+
+// Deleted returns true if the entry is the tombstone of a deleted DataKey
+func (e HistoryEntry) Deleted() bool {
+	return e.Index == TombstoneIndex
+}
+
+// HistoryIterator is used to iterate over the history of a DataKey
+type HistoryIterator func(HistoryEntry) bool
+
+// historyPrefix returns the history prefix of the key.  The DataKey is length
+// prefixed so the history of one key never shares a prefix with another
+func (ks dataKeyKeyspace) historyPrefix(key bcpb.DataKey) []byte {
+	return concatKey(ks.histPrefix, appendBytes(nil, key))
+}
+
+func (ks dataKeyKeyspace) historyKey(key bcpb.DataKey, height, seq uint32) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, height)
+	binary.BigEndian.PutUint32(b[4:], seq)
+	return concatKey(ks.historyPrefix(key), b)
+}
+
+// historyHeightPrefix returns the prefix of the states of the key recorded at
+// the height
+func (ks dataKeyKeyspace) historyHeightPrefix(key bcpb.DataKey, height uint32) []byte {
+	k := ks.historyKey(key, height, 0)
+	return k[:len(k)-4]
+}
+
+// AddHistory records the state of the DataKey set by a block
+func (index *BadgerDataKeyIndex) AddHistory(batch Batch, key bcpb.DataKey, e HistoryEntry) error {
+	k := index.historyKey(key, e.Height, e.Seq)
+	val := encodeHistoryEntry(e)
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(k, val)
+	})
+}
+
+// History iterates over the recorded states of the DataKey in the order they
+// were written
+func (index *BadgerDataKeyIndex) History(key bcpb.DataKey, f HistoryIterator) error {
+	pfx := index.historyPrefix(key)
+
+	return index.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(pfx); iter.Valid(); iter.Next() {
+			item := iter.Item()
+			k := item.Key()
+			if !bytes.HasPrefix(k, pfx) {
+				break
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			e, err := decodeHistoryEntry(k[len(pfx):], val)
+			if err != nil {
+				return err
+			}
+
+			if !f(e) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveHistory removes the states of the DataKey recorded at the height
+func (index *BadgerDataKeyIndex) RemoveHistory(batch Batch, key bcpb.DataKey, height uint32) error {
+	pfx := index.historyHeightPrefix(key, height)
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		keys := make([][]byte, 0, 1)
+
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		for iter.Seek(pfx); iter.Valid(); iter.Next() {
+			k := iter.Item().Key()
+			if !bytes.HasPrefix(k, pfx) {
+				break
+			}
+			keys = append(keys, concatKey(k))
+		}
+		iter.Close()
+
+		for _, k := range keys {
+			if err := txn.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeHistoryEntry encodes the value of the entry i.e. the big endian
+// timestamp and output index followed by the ref
+func encodeHistoryEntry(e HistoryEntry) []byte {
+	val := make([]byte, 12, 12+len(e.Ref))
+	binary.BigEndian.PutUint64(val, uint64(e.Timestamp))
+	binary.BigEndian.PutUint32(val[8:], uint32(e.Index))
+	return append(val, e.Ref...)
+}
+
+// decodeHistoryEntry decodes the entry from the height and sequence suffix of
+// its key and its value
+func decodeHistoryEntry(suffix, val []byte) (HistoryEntry, error) {
+	var e HistoryEntry
+	if len(suffix) != 8 || len(val) < 12 {
+		return e, errInvalidHistoryEntry
+	}
+
+	e.Height = binary.BigEndian.Uint32(suffix)
+	e.Seq = binary.BigEndian.Uint32(suffix[4:])
+	e.Timestamp = int64(binary.BigEndian.Uint64(val))
+	e.Index = int32(binary.BigEndian.Uint32(val[8:]))
+	e.Ref = bcpb.Digest(val[12:])
+
+	return e, nil
+}
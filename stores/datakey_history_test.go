@@ -0,0 +1,53 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+func Test_DataKeyIndex_History(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "dk-history-")
+	defer os.RemoveAll(tmpdir)
+
+	db, err := testBadgerDB(tmpdir)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	idx := NewBadgerDataKeyIndex(db, []byte("hist/"))
+	z := bcpb.NewZeroDigest(hasher.Default())
+
+	key := bcpb.DataKey("asset:1")
+	entries := []HistoryEntry{
+		{Height: 1, Timestamp: 10, Ref: z, Index: 0},
+		{Height: 2, Timestamp: 20, Ref: z, Index: 1},
+		{Height: 2, Timestamp: 20, Seq: 1, Ref: z, Index: TombstoneIndex},
+		{Height: 3, Timestamp: 30, Ref: z, Index: 2},
+	}
+	for _, e := range entries {
+		assert.Nil(t, idx.AddHistory(nil, key, e))
+	}
+	// A key sharing the prefix is kept separate
+	assert.Nil(t, idx.AddHistory(nil, bcpb.DataKey("asset:10"), entries[0]))
+
+	list := func() []HistoryEntry {
+		out := make([]HistoryEntry, 0)
+		assert.Nil(t, idx.History(key, func(e HistoryEntry) bool {
+			out = append(out, e)
+			return true
+		}))
+		return out
+	}
+
+	got := list()
+	assert.Equal(t, entries, got)
+	assert.True(t, got[2].Deleted())
+
+	assert.Nil(t, idx.RemoveHistory(nil, key, 2))
+	assert.Equal(t, []HistoryEntry{entries[0], entries[3]}, list())
+}
@@ -3,6 +3,7 @@ package stores
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 
 	"github.com/dgraph-io/badger"
 	"github.com/hexablock/blockchain/bcpb"
@@ -10,31 +11,93 @@ import (
 
 const (
 	idxSubkeyPrefix = "idx/"
+	// Block undo log key sub prefix
+	undoSubkeyPrefix = "undo/"
+)
+
+var (
+	// ErrDataKeyNotFound is returned when a DataKey is not in the index
+	ErrDataKeyNotFound = errors.New("data key not found")
+	// ErrDataKeyDeleted is returned when a DataKey has been deleted i.e. the
+	// index holds its tombstone
+	ErrDataKeyDeleted = errors.New("data key deleted")
+
+	errInvalidUndoLog = errors.New("invalid undo log")
+	errUndoNotFound   = errors.New("undo log not found")
 )
 
 // DataKeyIterator is used to iterate over the datakey index
 type DataKeyIterator func(bcpb.DataKey, bcpb.Digest, int32) bool
 
+// TombstoneIndex is the output index recorded for a deleted DataKey.  The ref
+// of a tombstone is the tx that deleted the DataKey
+const TombstoneIndex int32 = -1
+
+// UndoEntry is the state of a DataKey before a block was committed.  A nil Ref
+// means the DataKey did not exist
+type UndoEntry struct {
+	Key   bcpb.DataKey
+	Ref   bcpb.Digest
+	Index int32
+}
+
+// Deleted returns true if the entry is the tombstone of a deleted DataKey
+func (e UndoEntry) Deleted() bool {
+	return e.Ref != nil && e.Index == TombstoneIndex
+}
+
+// dataKeyKeyspace holds the key layout of a DataKeyIndex
+type dataKeyKeyspace struct {
+	prefix     []byte
+	undoPrefix []byte
+	histPrefix []byte
+}
+
+func newDataKeyKeyspace(prefix []byte) dataKeyKeyspace {
+	return dataKeyKeyspace{
+		prefix:     concatKey(prefix, []byte(idxSubkeyPrefix)),
+		undoPrefix: concatKey(prefix, []byte(undoSubkeyPrefix)),
+		histPrefix: concatKey(prefix, []byte(histSubkeyPrefix)),
+	}
+}
+
+func (ks dataKeyKeyspace) getkey(key bcpb.DataKey) []byte {
+	return concatKey(ks.prefix, key)
+}
+
+func (ks dataKeyKeyspace) undoKey(block bcpb.Digest) []byte {
+	return concatKey(ks.undoPrefix, block)
+}
+
+// encodeDataKeyState encodes the big endian output index followed by the ref
+func encodeDataKeyState(ref bcpb.Digest, idx int32) []byte {
+	b := make([]byte, 4, 4+len(ref))
+	binary.BigEndian.PutUint32(b, uint32(idx))
+	return append(b, ref...)
+}
+
+func decodeDataKeyState(val []byte) (bcpb.Digest, int32) {
+	return bcpb.Digest(val[4:]), int32(binary.BigEndian.Uint32(val[:4]))
+}
+
 // BadgerDataKeyIndex implements the DataKeyIndex interface backed by badger
 // key-value store
 type BadgerDataKeyIndex struct {
-	db     *badger.DB
-	prefix []byte
+	db *badger.DB
+	dataKeyKeyspace
 }
 
 // NewBadgerDataKeyIndex inits a new BadgerDataKeyIndex
 func NewBadgerDataKeyIndex(db *badger.DB, prefix []byte) *BadgerDataKeyIndex {
 	return &BadgerDataKeyIndex{
-		db:     db,
-		prefix: append(prefix, []byte(idxSubkeyPrefix)...),
+		db:              db,
+		dataKeyKeyspace: newDataKeyKeyspace(prefix),
 	}
 }
 
-func (index *BadgerDataKeyIndex) getkey(key bcpb.DataKey) []byte {
-	return append(index.prefix, key...)
-}
-
-// Get retrieves the digest and output index associated to the DataKey
+// Get retrieves the digest and output index associated to the DataKey.  If the
+// DataKey has been deleted ErrDataKeyDeleted is returned along with the digest
+// of the deleting tx and TombstoneIndex
 func (index *BadgerDataKeyIndex) Get(key bcpb.DataKey) (bcpb.Digest, int32, error) {
 	var (
 		i      int32
@@ -45,6 +108,9 @@ func (index *BadgerDataKeyIndex) Get(key bcpb.DataKey) (bcpb.Digest, int32, erro
 	err := index.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(k)
 		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = ErrDataKeyNotFound
+			}
 			return err
 		}
 		val, err := item.ValueCopy(nil)
@@ -52,27 +118,47 @@ func (index *BadgerDataKeyIndex) Get(key bcpb.DataKey) (bcpb.Digest, int32, erro
 			return err
 		}
 
-		i = int32(binary.BigEndian.Uint32(val[:4]))
-		digest = bcpb.Digest(val[4:])
+		digest, i = decodeDataKeyState(val)
+		if i == TombstoneIndex {
+			return ErrDataKeyDeleted
+		}
 		return nil
 	})
 
 	return digest, i, err
 }
 
-// Set sets the DataKey to the given tx id and output index
-func (index *BadgerDataKeyIndex) Set(key bcpb.DataKey, ref bcpb.Digest, idx int32) error {
-	b := make([]byte, 4)
-	binary.BigEndian.PutUint32(b, uint32(idx))
+// Set sets the DataKey to the given tx id and output index.  If a batch is
+// given the write is made as part of it
+func (index *BadgerDataKeyIndex) Set(batch Batch, key bcpb.DataKey, ref bcpb.Digest, idx int32) error {
 	k := index.getkey(key)
+	val := encodeDataKeyState(ref, idx)
 
-	return index.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(k, append(b, ref...))
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(k, val)
 	})
 }
 
-// Iter iterates over all DataKeys starting at the given prefix DataKey
+// Delete marks the DataKey as deleted by the given tx by setting its tombstone.
+// The DataKey can be set again afterwards
+func (index *BadgerDataKeyIndex) Delete(batch Batch, key bcpb.DataKey, ref bcpb.Digest) error {
+	return index.Set(batch, key, ref, TombstoneIndex)
+}
+
+// Iter iterates over all DataKeys starting at the given prefix DataKey.
+// Deleted DataKeys are skipped
 func (index *BadgerDataKeyIndex) Iter(prefix bcpb.DataKey, f DataKeyIterator) error {
+	return index.iter(prefix, false, f)
+}
+
+// IterAll iterates over all DataKeys starting at the given prefix DataKey
+// including deleted ones.  These are given with the digest of the deleting tx
+// and TombstoneIndex
+func (index *BadgerDataKeyIndex) IterAll(prefix bcpb.DataKey, f DataKeyIterator) error {
+	return index.iter(prefix, true, f)
+}
+
+func (index *BadgerDataKeyIndex) iter(prefix bcpb.DataKey, tombstones bool, f DataKeyIterator) error {
 	pfx := index.getkey(prefix)
 
 	return index.db.View(func(txn *badger.Txn) error {
@@ -91,8 +177,10 @@ func (index *BadgerDataKeyIndex) Iter(prefix bcpb.DataKey, f DataKeyIterator) er
 				return err
 			}
 
-			i := int32(binary.BigEndian.Uint32(val[:4]))
-			digest := bcpb.Digest(val[4:])
+			digest, i := decodeDataKeyState(val)
+			if i == TombstoneIndex && !tombstones {
+				continue
+			}
 
 			if !f(bcpb.DataKey(k), digest, i) {
 				break
@@ -102,3 +190,133 @@ func (index *BadgerDataKeyIndex) Iter(prefix bcpb.DataKey, f DataKeyIterator) er
 		return nil
 	})
 }
+
+// Remove removes the DataKey from the index
+func (index *BadgerDataKeyIndex) Remove(batch Batch, key bcpb.DataKey) error {
+	k := index.getkey(key)
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(k)
+	})
+}
+
+// SetUndo sets the undo log for the block i.e. the state of each DataKey
+// written by the block before it was committed
+func (index *BadgerDataKeyIndex) SetUndo(batch Batch, block bcpb.Digest, entries []UndoEntry) error {
+	k := index.undoKey(block)
+	val := encodeUndoEntries(entries)
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(k, val)
+	})
+}
+
+// Undo returns the undo log for the block
+func (index *BadgerDataKeyIndex) Undo(block bcpb.Digest) ([]UndoEntry, error) {
+	var (
+		entries []UndoEntry
+		k       = index.undoKey(block)
+	)
+
+	err := index.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(k)
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = errUndoNotFound
+			}
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err == nil {
+			entries, err = decodeUndoEntries(val)
+		}
+		return err
+	})
+
+	return entries, err
+}
+
+// RemoveUndo removes the undo log for the block
+func (index *BadgerDataKeyIndex) RemoveUndo(batch Batch, block bcpb.Digest) error {
+	k := index.undoKey(block)
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(k)
+	})
+}
+
+// encodeUndoEntries encodes each entry as the length prefixed key and ref
+// followed by the output index
+func encodeUndoEntries(entries []UndoEntry) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(len(entries)))
+
+	for _, e := range entries {
+		buf = appendBytes(buf, e.Key)
+		buf = appendBytes(buf, e.Ref)
+
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(e.Index))
+		buf = append(buf, b...)
+	}
+
+	return buf
+}
+
+func decodeUndoEntries(buf []byte) ([]UndoEntry, error) {
+	if len(buf) < 4 {
+		return nil, errInvalidUndoLog
+	}
+	c := binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+
+	entries := make([]UndoEntry, 0, c)
+	for i := uint32(0); i < c; i++ {
+		var (
+			e   UndoEntry
+			key []byte
+			ref []byte
+			ok  bool
+		)
+
+		if key, buf, ok = readBytes(buf); !ok {
+			return nil, errInvalidUndoLog
+		}
+		if ref, buf, ok = readBytes(buf); !ok {
+			return nil, errInvalidUndoLog
+		}
+		if len(buf) < 4 {
+			return nil, errInvalidUndoLog
+		}
+
+		e.Key = bcpb.DataKey(key)
+		if len(ref) > 0 {
+			e.Ref = bcpb.Digest(ref)
+		}
+		e.Index = int32(binary.BigEndian.Uint32(buf[:4]))
+		buf = buf[4:]
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// appendBytes appends the uint32 length prefixed bytes to buf
+func appendBytes(buf, b []byte) []byte {
+	l := make([]byte, 4)
+	binary.BigEndian.PutUint32(l, uint32(len(b)))
+	return append(append(buf, l...), b...)
+}
+
+// readBytes reads uint32 length prefixed bytes from buf returning the bytes and
+// the remainder of buf
+func readBytes(buf []byte) ([]byte, []byte, bool) {
+	if len(buf) < 4 {
+		return nil, buf, false
+	}
+	l := binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+	if uint32(len(buf)) < l {
+		return nil, buf, false
+	}
+	return buf[:l], buf[l:], true
+}
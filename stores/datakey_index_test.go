@@ -25,7 +25,7 @@ func Test_DataKeyIndex(t *testing.T) {
 
 	key := bcpb.DataKey("/ball")
 	z := bcpb.NewZeroDigest(hasher.Default())
-	err = idx.Set(key, z, 0)
+	err = idx.Set(nil, key, z, 0)
 	assert.Nil(t, err)
 
 	ref, i, err := idx.Get(key)
@@ -36,7 +36,7 @@ func Test_DataKeyIndex(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		k := bcpb.DataKey(fmt.Sprintf("/nums/%d", i))
 		z := bcpb.NewZeroDigest(hasher.Default())
-		err = idx.Set(k, z, int32(i))
+		err = idx.Set(nil, k, z, int32(i))
 		assert.Nil(t, err)
 	}
 
@@ -53,6 +53,26 @@ func Test_DataKeyIndex(t *testing.T) {
 		return true
 	})
 	assert.Equal(t, 5, c)
+
+	// Deleted keys return the deleting tx and are skipped when iterating
+	d := bcpb.Digest([]byte("deleting-tx"))
+	assert.Nil(t, idx.Delete(nil, key, d))
+	ref, i, err = idx.Get(key)
+	assert.Equal(t, ErrDataKeyDeleted, err)
+	assert.Equal(t, d, ref)
+	assert.Equal(t, TombstoneIndex, i)
+
+	c = 0
+	idx.Iter(bcpb.DataKey(""), func(k bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		c++
+		return true
+	})
+	assert.Equal(t, 5, c)
+
+	assert.Nil(t, idx.Set(nil, key, z, 1))
+	_, i, err = idx.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), i)
 }
 
 func testBadgerDB(tmpdir string) (*badger.DB, error) {
@@ -0,0 +1,395 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	proto "github.com/gogo/protobuf/proto"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+// MemBlockStorage implements the BlockStorage interface backed by a MemDB.  It
+// uses the same key layout as BadgerBlockStorage
+type MemBlockStorage struct {
+	hasher hasher.Hasher
+
+	// prefix for all stored keys
+	prefix []byte
+
+	db *MemDB
+}
+
+// NewMemBlockStorage inits a new MemBlockStorage
+func NewMemBlockStorage(db *MemDB, keyPrefix []byte, h hasher.Hasher) *MemBlockStorage {
+	return &MemBlockStorage{
+		db:     db,
+		hasher: h,
+		prefix: concatKey(keyPrefix, []byte(blkSubkeyPrefix)),
+	}
+}
+
+func (st *MemBlockStorage) getkey(key []byte) []byte {
+	return concatKey(st.prefix, key)
+}
+
+// Get returns the block by the given id or ErrBlockNotFound
+func (st *MemBlockStorage) Get(id bcpb.Digest) (*bcpb.Block, error) {
+	var blk *bcpb.Block
+	err := st.db.view(func(txn *memTxn) error {
+		var err error
+		blk, err = st.getBlock(txn, id)
+		return err
+	})
+	return blk, err
+}
+
+// Genesis returns the genesis block
+func (st *MemBlockStorage) Genesis() (bcpb.Digest, *bcpb.Block) {
+	return st.getPointerBlock([]byte(blkGenesisSubkeyPrefix))
+}
+
+// Last returns the last block
+func (st *MemBlockStorage) Last() (bcpb.Digest, *bcpb.Block) {
+	return st.getPointerBlock([]byte(blkLastSubkeyPrefix))
+}
+
+// LastExec returns the last executed block
+func (st *MemBlockStorage) LastExec() (bcpb.Digest, *bcpb.Block) {
+	return st.getPointerBlock([]byte(blkExecSubkeyPrefix))
+}
+
+// Exists returns true if the block exists
+func (st *MemBlockStorage) Exists(id bcpb.Digest) bool {
+	var ok bool
+	st.db.view(func(txn *memTxn) error {
+		ok = txn.exists(st.getkey(id))
+		return nil
+	})
+	return ok
+}
+
+// Add adds the block returning ErrBlockExists if it already exists
+func (st *MemBlockStorage) Add(batch Batch, b *bcpb.Block) (bcpb.Digest, error) {
+	id := b.Header.Hash(st.hasher.Clone())
+	key := st.getkey(id)
+
+	buf, err := proto.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = memUpdate(st.db, batch, func(txn *memTxn) error {
+		if txn.exists(key) {
+			return ErrBlockExists
+		}
+		txn.set(key, buf)
+		return nil
+	})
+
+	return id, err
+}
+
+// SetGenesis sets the genesis block pointer
+func (st *MemBlockStorage) SetGenesis(batch Batch, id bcpb.Digest) error {
+	return st.setPointer(batch, []byte(blkGenesisSubkeyPrefix), id)
+}
+
+// SetLast sets the last block pointer
+func (st *MemBlockStorage) SetLast(batch Batch, id bcpb.Digest) error {
+	return st.setPointer(batch, []byte(blkLastSubkeyPrefix), id)
+}
+
+// SetLastExec checks if the given digest exists and marks it as the last
+// executed block
+func (st *MemBlockStorage) SetLastExec(batch Batch, id bcpb.Digest) error {
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		if !txn.exists(st.getkey(id)) {
+			return ErrBlockNotFound
+		}
+		txn.set(st.getkey([]byte(blkExecSubkeyPrefix)), id)
+		return nil
+	})
+}
+
+func (st *MemBlockStorage) setPointer(batch Batch, name []byte, id bcpb.Digest) error {
+	key := st.getkey(name)
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, id)
+		return nil
+	})
+}
+
+// Remove removes the block along with its weight, tip entry and commit
+// certificate
+func (st *MemBlockStorage) Remove(batch Batch, id bcpb.Digest) error {
+	keys := [][]byte{
+		st.getkey(id),
+		concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id),
+		concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id),
+		concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id),
+	}
+
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		for _, key := range keys {
+			txn.delete(key)
+		}
+		return nil
+	})
+}
+
+// Weight returns the cumulative signature weight of the branch ending at the
+// given block
+func (st *MemBlockStorage) Weight(id bcpb.Digest) (uint64, error) {
+	key := concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id)
+
+	var weight uint64
+	err := st.db.view(func(txn *memTxn) error {
+		val, err := txn.get(key)
+		if err != nil {
+			return ErrBlockNotFound
+		}
+		weight = binary.BigEndian.Uint64(val)
+		return nil
+	})
+
+	return weight, err
+}
+
+// SetWeight sets the cumulative signature weight of the branch ending at the
+// given block
+func (st *MemBlockStorage) SetWeight(batch Batch, id bcpb.Digest, weight uint64) error {
+	key := concatKey(st.prefix, []byte(blkWeightSubkeyPrefix), id)
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, weight)
+
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, val)
+		return nil
+	})
+}
+
+// Certificate returns the commit certificate of the block
+func (st *MemBlockStorage) Certificate(id bcpb.Digest) (*bcpb.CommitCertificate, error) {
+	key := concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id)
+
+	var data []byte
+	err := st.db.view(func(txn *memTxn) error {
+		var err error
+		data, err = txn.get(key)
+		return err
+	})
+	if err != nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	var cert bcpb.CommitCertificate
+	err = proto.Unmarshal(data, &cert)
+	return &cert, err
+}
+
+// SetCertificate sets the commit certificate of the block
+func (st *MemBlockStorage) SetCertificate(batch Batch, id bcpb.Digest, cert *bcpb.CommitCertificate) error {
+	key := concatKey(st.prefix, []byte(blkCertSubkeyPrefix), id)
+	val, err := proto.Marshal(cert)
+	if err != nil {
+		return err
+	}
+
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, val)
+		return nil
+	})
+}
+
+// Tips returns the digests of all blocks marked as branch heads in key order
+func (st *MemBlockStorage) Tips() []bcpb.Digest {
+	prefix := concatKey(st.prefix, []byte(blkTipSubkeyPrefix))
+	tips := make([]bcpb.Digest, 0)
+
+	st.db.view(func(txn *memTxn) error {
+		txn.iterate(prefix, prefix, func(key, val []byte) bool {
+			tips = append(tips, bcpb.Digest(bytes.TrimPrefix(key, prefix)))
+			return true
+		})
+		return nil
+	})
+
+	return tips
+}
+
+// SetTip marks the block as a branch head
+func (st *MemBlockStorage) SetTip(batch Batch, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id)
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, nil)
+		return nil
+	})
+}
+
+// RemoveTip unmarks the block as a branch head
+func (st *MemBlockStorage) RemoveTip(batch Batch, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkTipSubkeyPrefix), id)
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.delete(key)
+		return nil
+	})
+}
+
+// Iter iterates over each block in key order
+func (st *MemBlockStorage) Iter(f BlockIterator) error {
+	prefix := st.getkey([]byte(st.hasher.Name()))
+
+	var err error
+	st.db.view(func(txn *memTxn) error {
+		txn.iterate(prefix, prefix, func(key, val []byte) bool {
+			var block bcpb.Block
+			if err = proto.Unmarshal(val, &block); err != nil {
+				return false
+			}
+
+			bid := bytes.TrimPrefix(key, st.prefix)
+			err = f(bid, &block)
+			return err == nil
+		})
+		return nil
+	})
+
+	return err
+}
+
+// GetByHeight returns the main chain block at the height with its Digest set
+// or ErrBlockNotFound
+func (st *MemBlockStorage) GetByHeight(height uint32) (*bcpb.Block, error) {
+	var blk *bcpb.Block
+	err := st.db.view(func(txn *memTxn) error {
+		var err error
+		blk, err = st.getHeightBlock(txn, height)
+		return err
+	})
+	return blk, err
+}
+
+// IterHeight iterates over the main chain blocks by height starting at the
+// given height in ascending or, if reverse, descending order.  A limit of zero
+// or less iterates over all of them.  The Digest of each block is set
+func (st *MemBlockStorage) IterHeight(start uint32, limit int, reverse bool, f BlockIterator) error {
+	return st.db.view(func(txn *memTxn) error {
+		return iterHeights(start, limit, reverse, func(h uint32) (*bcpb.Block, error) {
+			return st.getHeightBlock(txn, h)
+		}, f)
+	})
+}
+
+// SetHeight sets the main chain block at the height
+func (st *MemBlockStorage) SetHeight(batch Batch, height uint32, id bcpb.Digest) error {
+	key := concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height))
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, id)
+		return nil
+	})
+}
+
+// RemoveHeight removes the main chain block at the height
+func (st *MemBlockStorage) RemoveHeight(batch Batch, height uint32) error {
+	key := concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height))
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.delete(key)
+		return nil
+	})
+}
+
+// SignerSet returns the signer set activated at the highest height not above
+// the given one or ErrSignerSetNotFound
+func (st *MemBlockStorage) SignerSet(height uint32) (*bcpb.SignerSet, error) {
+	prefix := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix))
+	last := heightKey(height)
+
+	var data []byte
+	st.db.view(func(txn *memTxn) error {
+		txn.iterate(prefix, prefix, func(key, val []byte) bool {
+			if bytes.Compare(key[len(prefix):], last) > 0 {
+				return false
+			}
+			data = val
+			return true
+		})
+		return nil
+	})
+
+	if data == nil {
+		return nil, ErrSignerSetNotFound
+	}
+
+	var set bcpb.SignerSet
+	err := proto.Unmarshal(data, &set)
+	return &set, err
+}
+
+// SetSignerSet sets the signer set activated at the height
+func (st *MemBlockStorage) SetSignerSet(batch Batch, height uint32, set *bcpb.SignerSet) error {
+	key := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix), heightKey(height))
+	val, err := proto.Marshal(set)
+	if err != nil {
+		return err
+	}
+
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.set(key, val)
+		return nil
+	})
+}
+
+// RemoveSignerSet removes the signer set activated at the height
+func (st *MemBlockStorage) RemoveSignerSet(batch Batch, height uint32) error {
+	key := concatKey(st.prefix, []byte(blkSignerSetSubkeyPrefix), heightKey(height))
+	return memUpdate(st.db, batch, func(txn *memTxn) error {
+		txn.delete(key)
+		return nil
+	})
+}
+
+func (st *MemBlockStorage) getHeightBlock(txn *memTxn, height uint32) (*bcpb.Block, error) {
+	id, err := txn.get(concatKey(st.prefix, []byte(blkHeightSubkeyPrefix), heightKey(height)))
+	if err != nil {
+		return nil, ErrBlockNotFound
+	}
+
+	blk, err := st.getBlock(txn, id)
+	if err == nil {
+		blk.Digest = bcpb.Digest(id)
+	}
+	return blk, err
+}
+
+func (st *MemBlockStorage) getBlock(txn *memTxn, id bcpb.Digest) (*bcpb.Block, error) {
+	val, err := txn.get(st.getkey(id))
+	if err != nil {
+		return nil, ErrBlockNotFound
+	}
+
+	var blk bcpb.Block
+	err = proto.Unmarshal(val, &blk)
+	return &blk, err
+}
+
+// getPointerBlock returns the block the pointer is set to.  The id is nil if
+// the pointer is not set and the block nil if it does not exist
+func (st *MemBlockStorage) getPointerBlock(name []byte) (id bcpb.Digest, blk *bcpb.Block) {
+	st.db.view(func(txn *memTxn) error {
+		val, err := txn.get(st.getkey(name))
+		if err != nil {
+			return err
+		}
+
+		id = bcpb.Digest(val)
+		blk, err = st.getBlock(txn, id)
+		if err != nil {
+			blk = nil
+		}
+		return err
+	})
+
+	return id, blk
+}
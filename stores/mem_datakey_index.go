@@ -0,0 +1,181 @@
+package stores
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+// MemDataKeyIndex implements the DataKeyIndex interface backed by a MemDB.  It
+// uses the same key layout as BadgerDataKeyIndex
+type MemDataKeyIndex struct {
+	db *MemDB
+	dataKeyKeyspace
+}
+
+// NewMemDataKeyIndex inits a new MemDataKeyIndex
+func NewMemDataKeyIndex(db *MemDB, prefix []byte) *MemDataKeyIndex {
+	return &MemDataKeyIndex{
+		db:              db,
+		dataKeyKeyspace: newDataKeyKeyspace(prefix),
+	}
+}
+
+// Get retrieves the digest and output index associated to the DataKey.  If the
+// DataKey has been deleted ErrDataKeyDeleted is returned along with the digest
+// of the deleting tx and TombstoneIndex
+func (index *MemDataKeyIndex) Get(key bcpb.DataKey) (bcpb.Digest, int32, error) {
+	var (
+		i      int32
+		digest bcpb.Digest
+	)
+
+	err := index.db.view(func(txn *memTxn) error {
+		val, err := txn.get(index.getkey(key))
+		if err != nil {
+			return ErrDataKeyNotFound
+		}
+
+		digest, i = decodeDataKeyState(val)
+		if i == TombstoneIndex {
+			return ErrDataKeyDeleted
+		}
+		return nil
+	})
+
+	return digest, i, err
+}
+
+// Set sets the DataKey to the given tx id and output index.  If a batch is
+// given the write is made as part of it
+func (index *MemDataKeyIndex) Set(batch Batch, key bcpb.DataKey, ref bcpb.Digest, idx int32) error {
+	k := index.getkey(key)
+	val := encodeDataKeyState(ref, idx)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.set(k, val)
+		return nil
+	})
+}
+
+// Delete marks the DataKey as deleted by the given tx by setting its tombstone.
+// The DataKey can be set again afterwards
+func (index *MemDataKeyIndex) Delete(batch Batch, key bcpb.DataKey, ref bcpb.Digest) error {
+	return index.Set(batch, key, ref, TombstoneIndex)
+}
+
+// Iter iterates over all DataKeys starting at the given prefix DataKey in key
+// order.  Deleted DataKeys are skipped
+func (index *MemDataKeyIndex) Iter(prefix bcpb.DataKey, f DataKeyIterator) error {
+	return index.iter(prefix, false, f)
+}
+
+// IterAll iterates over all DataKeys starting at the given prefix DataKey in
+// key order including deleted ones.  These are given with the digest of the
+// deleting tx and TombstoneIndex
+func (index *MemDataKeyIndex) IterAll(prefix bcpb.DataKey, f DataKeyIterator) error {
+	return index.iter(prefix, true, f)
+}
+
+func (index *MemDataKeyIndex) iter(prefix bcpb.DataKey, tombstones bool, f DataKeyIterator) error {
+	pfx := index.getkey(prefix)
+
+	return index.db.view(func(txn *memTxn) error {
+		txn.iterate(pfx, pfx, func(key, val []byte) bool {
+			digest, i := decodeDataKeyState(val)
+			if i == TombstoneIndex && !tombstones {
+				return true
+			}
+			return f(bcpb.DataKey(key[len(index.prefix):]), digest, i)
+		})
+		return nil
+	})
+}
+
+// Remove removes the DataKey from the index
+func (index *MemDataKeyIndex) Remove(batch Batch, key bcpb.DataKey) error {
+	k := index.getkey(key)
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.delete(k)
+		return nil
+	})
+}
+
+// SetUndo sets the undo log for the block i.e. the state of each DataKey
+// written by the block before it was committed
+func (index *MemDataKeyIndex) SetUndo(batch Batch, block bcpb.Digest, entries []UndoEntry) error {
+	k := index.undoKey(block)
+	val := encodeUndoEntries(entries)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.set(k, val)
+		return nil
+	})
+}
+
+// Undo returns the undo log for the block
+func (index *MemDataKeyIndex) Undo(block bcpb.Digest) ([]UndoEntry, error) {
+	var entries []UndoEntry
+
+	err := index.db.view(func(txn *memTxn) error {
+		val, err := txn.get(index.undoKey(block))
+		if err != nil {
+			return errUndoNotFound
+		}
+		entries, err = decodeUndoEntries(val)
+		return err
+	})
+
+	return entries, err
+}
+
+// RemoveUndo removes the undo log for the block
+func (index *MemDataKeyIndex) RemoveUndo(batch Batch, block bcpb.Digest) error {
+	k := index.undoKey(block)
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.delete(k)
+		return nil
+	})
+}
+
+// AddHistory records the state of the DataKey set by a block
+func (index *MemDataKeyIndex) AddHistory(batch Batch, key bcpb.DataKey, e HistoryEntry) error {
+	k := index.historyKey(key, e.Height, e.Seq)
+	val := encodeHistoryEntry(e)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.set(k, val)
+		return nil
+	})
+}
+
+// History iterates over the recorded states of the DataKey in the order they
+// were written
+func (index *MemDataKeyIndex) History(key bcpb.DataKey, f HistoryIterator) error {
+	pfx := index.historyPrefix(key)
+
+	var err error
+	index.db.view(func(txn *memTxn) error {
+		txn.iterate(pfx, pfx, func(k, val []byte) bool {
+			var e HistoryEntry
+			if e, err = decodeHistoryEntry(k[len(pfx):], val); err != nil {
+				return false
+			}
+			return f(e)
+		})
+		return nil
+	})
+
+	return err
+}
+
+// RemoveHistory removes the states of the DataKey recorded at the height
+func (index *MemDataKeyIndex) RemoveHistory(batch Batch, key bcpb.DataKey, height uint32) error {
+	pfx := index.historyHeightPrefix(key, height)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		txn.iterate(pfx, pfx, func(k, val []byte) bool {
+			txn.delete(k)
+			return true
+		})
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+package stores
+
+import (
+	"bytes"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+// MemQueryIndex implements the QueryIndex interface backed by a MemDB.  It uses
+// the same key layout as BadgerQueryIndex
+type MemQueryIndex struct {
+	db *MemDB
+	queryKeyspace
+}
+
+// NewMemQueryIndex inits a new MemQueryIndex
+func NewMemQueryIndex(db *MemDB, prefix []byte) *MemQueryIndex {
+	return &MemQueryIndex{
+		db:            db,
+		queryKeyspace: newQueryKeyspace(prefix),
+	}
+}
+
+// Add indexes the Tags, Labels and Metrics of the output for the DataKey
+func (index *MemQueryIndex) Add(batch Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error {
+	keys := index.keys(key, txo)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		for _, k := range keys {
+			txn.set(k, nil)
+		}
+		return nil
+	})
+}
+
+// Remove removes the Tags, Labels and Metrics of the output for the DataKey
+func (index *MemQueryIndex) Remove(batch Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error {
+	keys := index.keys(key, txo)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		for _, k := range keys {
+			txn.delete(k)
+		}
+		return nil
+	})
+}
+
+// IterTag iterates over the DataKeys with the tag set to the value in DataKey
+// order
+func (index *MemQueryIndex) IterTag(name, value string, f DataKeyFunc) error {
+	pfx := index.tagKey(name, value)
+	return index.iter(pfx, pfx, 0, nil, f)
+}
+
+// IterLabel iterates over the DataKeys with the label in DataKey order
+func (index *MemQueryIndex) IterLabel(label string, f DataKeyFunc) error {
+	pfx := index.labelKey(label)
+	return index.iter(pfx, pfx, 0, nil, f)
+}
+
+// IterMetric iterates over the DataKeys with the metric within min and max
+// inclusive in value order
+func (index *MemQueryIndex) IterMetric(name string, min, max float64, f DataKeyFunc) error {
+	pfx := index.metricKey(name)
+	start := concatKey(pfx, encodeMetric(min))
+
+	return index.iter(pfx, start, 8, encodeMetric(max), f)
+}
+
+// iter calls f with the DataKey of each key under the prefix starting at the
+// given key.  Each key holds skip bytes between the prefix and the DataKey.  If
+// an upper bound is given iteration stops once those bytes exceed it
+func (index *MemQueryIndex) iter(pfx, start []byte, skip int, upper []byte, f DataKeyFunc) error {
+	return index.db.view(func(txn *memTxn) error {
+		txn.iterate(pfx, start, func(k, val []byte) bool {
+			if len(k) < len(pfx)+skip {
+				return false
+			}
+
+			v := k[len(pfx) : len(pfx)+skip]
+			if upper != nil && bytes.Compare(v, upper) > 0 {
+				return false
+			}
+
+			return f(bcpb.DataKey(k[len(pfx)+skip:]))
+		})
+		return nil
+	})
+}
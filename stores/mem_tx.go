@@ -0,0 +1,157 @@
+package stores
+
+import (
+	"log"
+
+	proto "github.com/gogo/protobuf/proto"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+// MemTxStorage implements the TxStorage interface backed by a MemDB
+type MemTxStorage struct {
+	prefix    []byte
+	locPrefix []byte
+	db        *MemDB
+}
+
+// NewMemTxStorage returns a new in-memory tx storage device
+func NewMemTxStorage(db *MemDB, keyPrefix []byte) *MemTxStorage {
+	return &MemTxStorage{
+		prefix:    concatKey(keyPrefix, []byte(txSubkeyPrefix)),
+		locPrefix: concatKey(keyPrefix, []byte(txLocSubkeyPrefix)),
+		db:        db,
+	}
+}
+
+func (store *MemTxStorage) getkey(key []byte) []byte {
+	return concatKey(store.prefix, key)
+}
+
+func (store *MemTxStorage) locKey(id bcpb.Digest) []byte {
+	return concatKey(store.locPrefix, id)
+}
+
+// Get retrieves a transaction by the given id.  It returns ErrTxNotFound if the
+// transaction does not exist and ErrPruned if it has been pruned
+func (store *MemTxStorage) Get(id bcpb.Digest) (*bcpb.Tx, error) {
+	var val []byte
+	err := store.db.view(func(txn *memTxn) error {
+		var err error
+		val, err = txn.get(store.getkey(id))
+		return err
+	})
+
+	if err != nil {
+		return nil, ErrTxNotFound
+	}
+	if len(val) == 0 {
+		return nil, ErrPruned
+	}
+
+	var tx bcpb.Tx
+	err = proto.Unmarshal(val, &tx)
+	return &tx, err
+}
+
+// Iter iterates over each transaction, calling the f for each encountered
+// tx.  Pruned transactions are skipped
+func (store *MemTxStorage) Iter(f func(bcpb.Tx) error) {
+	store.db.view(func(txn *memTxn) error {
+		txn.iterate(store.prefix, store.prefix, func(key, val []byte) bool {
+			if len(val) == 0 {
+				return true
+			}
+
+			var tx bcpb.Tx
+			if err := proto.Unmarshal(val, &tx); err != nil {
+				log.Printf("[ERR] Failed to unmarshal tx key=%q: %v", key, err)
+				return true
+			}
+
+			return f(tx) == nil
+		})
+		return nil
+	})
+}
+
+// Set sets the transaction as part of the batch.  A nil batch writes the
+// transaction immediately
+func (store *MemTxStorage) Set(batch Batch, tx *bcpb.Tx) error {
+	return store.SetBatch(batch, []*bcpb.Tx{tx})
+}
+
+// SetBatch sets a batch of transactions as part of the write batch returning
+// an error if any one fails
+func (store *MemTxStorage) SetBatch(batch Batch, txs []*bcpb.Tx) error {
+	values := make([][]byte, len(txs))
+	for i := range txs {
+		b, err := proto.Marshal(txs[i])
+		if err != nil {
+			return err
+		}
+		values[i] = b
+	}
+
+	return memUpdate(store.db, batch, func(txn *memTxn) error {
+		for i := range txs {
+			txn.set(store.getkey(txs[i].Digest), values[i])
+		}
+		return nil
+	})
+}
+
+// Remove removes the transaction by the given id
+func (store *MemTxStorage) Remove(batch Batch, id bcpb.Digest) error {
+	key := store.getkey(id)
+	return memUpdate(store.db, batch, func(txn *memTxn) error {
+		txn.delete(key)
+		return nil
+	})
+}
+
+// Prune removes the body of the transaction by the given id.  An empty value is
+// kept in its place so Get can tell a pruned transaction from a missing one
+func (store *MemTxStorage) Prune(batch Batch, id bcpb.Digest) error {
+	key := store.getkey(id)
+	return memUpdate(store.db, batch, func(txn *memTxn) error {
+		txn.set(key, nil)
+		return nil
+	})
+}
+
+// Location returns the main chain location of the transaction by the given id
+// or ErrLocationNotFound
+func (store *MemTxStorage) Location(id bcpb.Digest) (TxLocation, error) {
+	var val []byte
+	err := store.db.view(func(txn *memTxn) error {
+		var err error
+		val, err = txn.get(store.locKey(id))
+		return err
+	})
+
+	if err != nil {
+		return TxLocation{}, ErrLocationNotFound
+	}
+	return decodeTxLocation(val)
+}
+
+// SetLocation sets the main chain location of the transaction by the given id
+func (store *MemTxStorage) SetLocation(batch Batch, id bcpb.Digest, loc TxLocation) error {
+	key := store.locKey(id)
+	val := encodeTxLocation(loc)
+	return memUpdate(store.db, batch, func(txn *memTxn) error {
+		txn.set(key, val)
+		return nil
+	})
+}
+
+// RemoveLocation removes the main chain location of the transaction by the
+// given id
+func (store *MemTxStorage) RemoveLocation(batch Batch, id bcpb.Digest) error {
+	key := store.locKey(id)
+	return memUpdate(store.db, batch, func(txn *memTxn) error {
+		txn.delete(key)
+		return nil
+	})
+}
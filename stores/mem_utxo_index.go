@@ -0,0 +1,101 @@
+package stores
+
+import (
+	"bytes"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+// MemUTXOIndex implements the UTXOIndex interface backed by a MemDB.  It uses
+// the same key layout as BadgerUTXOIndex
+type MemUTXOIndex struct {
+	db *MemDB
+	utxoKeyspace
+}
+
+// NewMemUTXOIndex inits a new MemUTXOIndex.  The hasher is used to compute
+// public key addresses
+func NewMemUTXOIndex(db *MemDB, prefix []byte, h hasher.Hasher) *MemUTXOIndex {
+	return &MemUTXOIndex{
+		db:           db,
+		utxoKeyspace: newUTXOKeyspace(prefix, h),
+	}
+}
+
+// Exists returns true if the output is unspent
+func (index *MemUTXOIndex) Exists(ref bcpb.Digest, idx int32) bool {
+	var ok bool
+	index.db.view(func(txn *memTxn) error {
+		ok = txn.exists(index.getkey(ref, idx))
+		return nil
+	})
+	return ok
+}
+
+// Add marks the output as unspent and indexes it by the address of each of its
+// public keys
+func (index *MemUTXOIndex) Add(batch Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error {
+	k := index.getkey(ref, idx)
+	keys := index.addrKeys(ref, idx, txo)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		for _, ak := range keys {
+			txn.set(ak, nil)
+		}
+		txn.set(k, nil)
+		return nil
+	})
+}
+
+// Remove marks the output as spent removing it from the address index
+func (index *MemUTXOIndex) Remove(batch Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error {
+	k := index.getkey(ref, idx)
+	keys := index.addrKeys(ref, idx, txo)
+
+	return memUpdate(index.db, batch, func(txn *memTxn) error {
+		for _, ak := range keys {
+			txn.delete(ak)
+		}
+		txn.delete(k)
+		return nil
+	})
+}
+
+// IterAddress iterates over the unspent outputs the address can unlock in
+// OutPoint order.  If after is not nil iteration starts after that OutPoint
+func (index *MemUTXOIndex) IterAddress(addr []byte, after []byte, f UTXOIterator) error {
+	pfx := index.getAddrPrefix(addr)
+	start := concatKey(pfx, after)
+
+	return index.db.view(func(txn *memTxn) error {
+		txn.iterate(pfx, start, func(key, val []byte) bool {
+			if after != nil && bytes.Equal(key, start) {
+				return true
+			}
+
+			ref, idx, ok := parseOutPoint(key[len(pfx):])
+			if !ok {
+				return true
+			}
+			return f(ref, idx)
+		})
+		return nil
+	})
+}
+
+// Iter iterates over all unspent outputs in OutPoint order
+func (index *MemUTXOIndex) Iter(f UTXOIterator) error {
+	pfx := index.prefix
+
+	return index.db.view(func(txn *memTxn) error {
+		txn.iterate(pfx, pfx, func(key, val []byte) bool {
+			ref, idx, ok := parseOutPoint(key[len(pfx):])
+			if !ok {
+				return true
+			}
+			return f(ref, idx)
+		})
+		return nil
+	})
+}
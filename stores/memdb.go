@@ -0,0 +1,223 @@
+package stores
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	errKeyNotFound    = errors.New("key not found")
+	errBatchDiscarded = errors.New("batch discarded")
+)
+
+// MemDB is a concurrency-safe in-memory key-value store backing the in-memory
+// stores.  Like a badger db it can be shared by all stores of a ledger, each
+// using its own key prefix, so writes can be batched across them.  Keys are
+// iterated in byte order by scanning all keys making it suited to tests,
+// simulators and ephemeral chains rather than large ledgers
+type MemDB struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemDB inits a new empty MemDB
+func NewMemDB() *MemDB {
+	return &MemDB{data: make(map[string][]byte)}
+}
+
+// view calls f with a read-only transaction
+func (db *MemDB) view(f func(*memTxn) error) error {
+	return f(&memTxn{db: db})
+}
+
+// update calls f with a read-write transaction committing it if f succeeds.
+// The db is locked for the duration of f
+func (db *MemDB) update(f func(*memTxn) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	txn := newMemTxn(db)
+	txn.locked = true
+	if err := f(txn); err != nil {
+		return err
+	}
+	txn.apply()
+
+	return nil
+}
+
+// memWrite is a pending write of a value or a delete
+type memWrite struct {
+	val []byte
+	del bool
+}
+
+// memTxn is a transaction on a MemDB.  Writes are held until the transaction
+// is applied and are visible to the reads of the transaction
+type memTxn struct {
+	db     *MemDB
+	writes map[string]memWrite
+	// Whether the db lock is already held by the transaction
+	locked bool
+}
+
+func newMemTxn(db *MemDB) *memTxn {
+	return &memTxn{db: db, writes: make(map[string]memWrite)}
+}
+
+func (txn *memTxn) rlock() {
+	if !txn.locked {
+		txn.db.mu.RLock()
+	}
+}
+
+func (txn *memTxn) runlock() {
+	if !txn.locked {
+		txn.db.mu.RUnlock()
+	}
+}
+
+// get returns a copy of the value of the key or errKeyNotFound
+func (txn *memTxn) get(key []byte) ([]byte, error) {
+	if w, ok := txn.writes[string(key)]; ok {
+		if w.del {
+			return nil, errKeyNotFound
+		}
+		return concatKey(w.val), nil
+	}
+
+	txn.rlock()
+	val, ok := txn.db.data[string(key)]
+	txn.runlock()
+
+	if !ok {
+		return nil, errKeyNotFound
+	}
+	return concatKey(val), nil
+}
+
+// exists returns true if the key has a value
+func (txn *memTxn) exists(key []byte) bool {
+	_, err := txn.get(key)
+	return err == nil
+}
+
+func (txn *memTxn) set(key, val []byte) {
+	txn.writes[string(key)] = memWrite{val: concatKey(val)}
+}
+
+func (txn *memTxn) delete(key []byte) {
+	txn.writes[string(key)] = memWrite{del: true}
+}
+
+// iterate calls f with each key under the prefix and its value in key order
+// starting at the given key.  The matching keys are collected before f is
+// called so f may use the db
+func (txn *memTxn) iterate(prefix, start []byte, f func(key, val []byte) bool) {
+	keys := make([]string, 0)
+	vals := make(map[string][]byte)
+
+	match := func(k string) bool {
+		return bytes.HasPrefix([]byte(k), prefix) && k >= string(start)
+	}
+
+	txn.rlock()
+	for k, v := range txn.db.data {
+		if match(k) {
+			vals[k] = v
+		}
+	}
+	txn.runlock()
+
+	for k, w := range txn.writes {
+		if !match(k) {
+			continue
+		}
+		if w.del {
+			delete(vals, k)
+		} else {
+			vals[k] = w.val
+		}
+	}
+
+	for k := range vals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !f([]byte(k), concatKey(vals[k])) {
+			break
+		}
+	}
+}
+
+// apply writes all pending writes to the db.  The db lock must be held
+func (txn *memTxn) apply() {
+	for k, w := range txn.writes {
+		if w.del {
+			delete(txn.db.data, k)
+		} else {
+			txn.db.data[k] = w.val
+		}
+	}
+	txn.writes = make(map[string]memWrite)
+}
+
+// MemBatcher creates batches for stores backed by a single MemDB
+type MemBatcher struct {
+	db *MemDB
+}
+
+// NewMemBatcher inits a new MemBatcher for the given db
+func NewMemBatcher(db *MemDB) *MemBatcher {
+	return &MemBatcher{db: db}
+}
+
+// NewBatch starts a new write batch
+func (b *MemBatcher) NewBatch() Batch {
+	return &memBatch{txn: newMemTxn(b.db)}
+}
+
+type memBatch struct {
+	txn  *memTxn
+	done bool
+}
+
+func (b *memBatch) Commit() error {
+	if b.done {
+		return errBatchDiscarded
+	}
+	b.done = true
+
+	b.txn.db.mu.Lock()
+	b.txn.apply()
+	b.txn.db.mu.Unlock()
+
+	return nil
+}
+
+func (b *memBatch) Discard() {
+	b.done = true
+	b.txn.writes = make(map[string]memWrite)
+}
+
+// memUpdate calls f with the transaction of the batch if one is given,
+// otherwise f is run in its own read-write transaction
+func memUpdate(db *MemDB, batch Batch, f func(*memTxn) error) error {
+	if batch == nil {
+		return db.update(f)
+	}
+
+	mb, ok := batch.(*memBatch)
+	if !ok || mb.txn.db != db {
+		return ErrBatchType
+	}
+	if mb.done {
+		return errBatchDiscarded
+	}
+
+	return f(mb.txn)
+}
@@ -0,0 +1,128 @@
+package stores
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+func Test_MemBatch(t *testing.T) {
+	db := NewMemDB()
+
+	h := hasher.Default()
+	batcher := NewMemBatcher(db)
+	bst := NewMemBlockStorage(db, []byte("batch/"), h)
+	tst := NewMemTxStorage(db, []byte("batch/"))
+	idx := NewMemDataKeyIndex(db, []byte("batch/"))
+
+	tx := bcpb.NewBaseTx()
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("batch:key")})
+	tx.SetDigest(h)
+
+	blk := bcpb.NewBlock()
+	blk.SetTxs([]*bcpb.Tx{tx}, h)
+	blk.SetHash(h)
+
+	write := func(batch Batch) {
+		assert.Nil(t, tst.Set(batch, tx))
+		id, err := bst.Add(batch, blk)
+		assert.Nil(t, err)
+		assert.Nil(t, bst.SetLast(batch, id))
+		// Reads within the batch see its writes
+		assert.Nil(t, bst.SetLastExec(batch, id))
+		assert.Nil(t, idx.Set(batch, bcpb.DataKey("batch:key"), tx.Digest, 0))
+	}
+
+	// Discarded writes are never visible
+	batch := batcher.NewBatch()
+	write(batch)
+	batch.Discard()
+
+	assert.False(t, bst.Exists(blk.Digest))
+	_, err := tst.Get(tx.Digest)
+	assert.NotNil(t, err)
+	_, _, err = idx.Get(bcpb.DataKey("batch:key"))
+	assert.Equal(t, ErrDataKeyNotFound, err)
+
+	// Committed writes are visible across all stores
+	batch = batcher.NewBatch()
+	write(batch)
+	assert.Nil(t, batch.Commit())
+	batch.Discard()
+
+	assert.True(t, bst.Exists(blk.Digest))
+	lid, _ := bst.Last()
+	assert.Equal(t, blk.Digest, lid)
+	_, err = tst.Get(tx.Digest)
+	assert.Nil(t, err)
+	ref, _, err := idx.Get(bcpb.DataKey("batch:key"))
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Digest, ref)
+
+	_, err = bst.Add(nil, blk)
+	assert.Equal(t, ErrBlockExists, err)
+
+	// Batches from another db are rejected
+	batch = NewMemBatcher(NewMemDB()).NewBatch()
+	defer batch.Discard()
+	assert.Equal(t, ErrBatchType, tst.Set(batch, tx))
+}
+
+func Test_MemDataKeyIndex(t *testing.T) {
+	db := NewMemDB()
+	idx := NewMemDataKeyIndex(db, []byte("foo"))
+	z := bcpb.NewZeroDigest(hasher.Default())
+
+	// Writes from many goroutines
+	var wg sync.WaitGroup
+	for i := 4; i >= 0; i-- {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			k := bcpb.DataKey(fmt.Sprintf("/nums/%d", i))
+			assert.Nil(t, idx.Set(nil, k, z, int32(i)))
+		}(i)
+	}
+	wg.Wait()
+	assert.Nil(t, idx.Set(nil, bcpb.DataKey("/ball"), z, 0))
+
+	keys := make([]string, 0)
+	idx.Iter(bcpb.DataKey("/nums"), func(k bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		keys = append(keys, string(k))
+		return true
+	})
+	assert.Equal(t, []string{"/nums/0", "/nums/1", "/nums/2", "/nums/3", "/nums/4"}, keys)
+
+	// Deleted keys are skipped
+	d := bcpb.Digest([]byte("deleting-tx"))
+	assert.Nil(t, idx.Delete(nil, bcpb.DataKey("/nums/2"), d))
+	ref, i, err := idx.Get(bcpb.DataKey("/nums/2"))
+	assert.Equal(t, ErrDataKeyDeleted, err)
+	assert.Equal(t, d, ref)
+	assert.Equal(t, TombstoneIndex, i)
+
+	var c int
+	idx.Iter(nil, func(k bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		c++
+		return true
+	})
+	assert.Equal(t, 5, c)
+
+	// History at a height is removed
+	for h := uint32(1); h <= 3; h++ {
+		assert.Nil(t, idx.AddHistory(nil, bcpb.DataKey("/ball"), HistoryEntry{Height: h, Ref: z}))
+	}
+	assert.Nil(t, idx.RemoveHistory(nil, bcpb.DataKey("/ball"), 2))
+
+	heights := make([]uint32, 0)
+	idx.History(bcpb.DataKey("/ball"), func(e HistoryEntry) bool {
+		heights = append(heights, e.Height)
+		return true
+	})
+	assert.Equal(t, []uint32{1, 3}, heights)
+}
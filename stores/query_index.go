@@ -0,0 +1,183 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+const (
+	// Query index key sub prefixes
+	tagSubkeyPrefix    = "qt/"
+	labelSubkeyPrefix  = "ql/"
+	metricSubkeyPrefix = "qm/"
+)
+
+// DataKeyFunc is called with each DataKey matched by a query index lookup
+type DataKeyFunc func(bcpb.DataKey) bool
+
+// queryKeyspace holds the key layout of a QueryIndex.  Tags and labels are
+// keyed by their length prefixed name and value followed by the DataKey.
+// Metrics are keyed by their length prefixed name, the order preserving
+// encoding of the value and the DataKey
+type queryKeyspace struct {
+	tagPrefix    []byte
+	labelPrefix  []byte
+	metricPrefix []byte
+}
+
+func newQueryKeyspace(prefix []byte) queryKeyspace {
+	return queryKeyspace{
+		tagPrefix:    concatKey(prefix, []byte(tagSubkeyPrefix)),
+		labelPrefix:  concatKey(prefix, []byte(labelSubkeyPrefix)),
+		metricPrefix: concatKey(prefix, []byte(metricSubkeyPrefix)),
+	}
+}
+
+func (ks queryKeyspace) tagKey(name, value string) []byte {
+	return appendBytes(appendBytes(concatKey(ks.tagPrefix), []byte(name)), []byte(value))
+}
+
+func (ks queryKeyspace) labelKey(label string) []byte {
+	return appendBytes(concatKey(ks.labelPrefix), []byte(label))
+}
+
+func (ks queryKeyspace) metricKey(name string) []byte {
+	return appendBytes(concatKey(ks.metricPrefix), []byte(name))
+}
+
+// BadgerQueryIndex implements the QueryIndex interface backed by badger
+// key-value store
+type BadgerQueryIndex struct {
+	db *badger.DB
+	queryKeyspace
+}
+
+// NewBadgerQueryIndex inits a new BadgerQueryIndex
+func NewBadgerQueryIndex(db *badger.DB, prefix []byte) *BadgerQueryIndex {
+	return &BadgerQueryIndex{
+		db:            db,
+		queryKeyspace: newQueryKeyspace(prefix),
+	}
+}
+
+// encodeMetric returns the big endian encoding of the value such that the
+// byte order matches the numeric order
+func encodeMetric(v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, bits)
+	return b
+}
+
+// keys returns all index keys of the output set for the DataKey
+func (ks queryKeyspace) keys(key bcpb.DataKey, txo *bcpb.TxOutput) [][]byte {
+	keys := make([][]byte, 0, len(txo.Tags)+len(txo.Labels)+len(txo.Metrics))
+
+	for k, v := range txo.Tags {
+		keys = append(keys, concatKey(ks.tagKey(k, v), key))
+	}
+	for _, l := range txo.Labels {
+		keys = append(keys, concatKey(ks.labelKey(l), key))
+	}
+	for k, v := range txo.Metrics {
+		keys = append(keys, concatKey(ks.metricKey(k), encodeMetric(v), key))
+	}
+
+	return keys
+}
+
+// Add indexes the Tags, Labels and Metrics of the output for the DataKey
+func (index *BadgerQueryIndex) Add(batch Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error {
+	keys := index.keys(key, txo)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		for _, k := range keys {
+			if err := txn.Set(k, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Remove removes the Tags, Labels and Metrics of the output for the DataKey
+func (index *BadgerQueryIndex) Remove(batch Batch, key bcpb.DataKey, txo *bcpb.TxOutput) error {
+	keys := index.keys(key, txo)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		for _, k := range keys {
+			if err := txn.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IterTag iterates over the DataKeys with the tag set to the value in DataKey
+// order
+func (index *BadgerQueryIndex) IterTag(name, value string, f DataKeyFunc) error {
+	pfx := index.tagKey(name, value)
+	return index.iter(pfx, pfx, 0, nil, f)
+}
+
+// IterLabel iterates over the DataKeys with the label in DataKey order
+func (index *BadgerQueryIndex) IterLabel(label string, f DataKeyFunc) error {
+	pfx := index.labelKey(label)
+	return index.iter(pfx, pfx, 0, nil, f)
+}
+
+// IterMetric iterates over the DataKeys with the metric within min and max
+// inclusive in value order
+func (index *BadgerQueryIndex) IterMetric(name string, min, max float64, f DataKeyFunc) error {
+	pfx := index.metricKey(name)
+	start := concatKey(pfx, encodeMetric(min))
+
+	return index.iter(pfx, start, 8, encodeMetric(max), f)
+}
+
+// iter calls f with the DataKey of each key under the prefix starting at the
+// given key.  Each key holds skip bytes between the prefix and the DataKey.  If
+// an upper bound is given iteration stops once those bytes exceed it
+func (index *BadgerQueryIndex) iter(pfx, start []byte, skip int, upper []byte, f DataKeyFunc) error {
+	return index.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(start); iter.Valid(); iter.Next() {
+			k := iter.Item().Key()
+			if !bytes.HasPrefix(k, pfx) || len(k) < len(pfx)+skip {
+				break
+			}
+
+			v := k[len(pfx) : len(pfx)+skip]
+			if upper != nil && bytes.Compare(v, upper) > 0 {
+				break
+			}
+
+			key := make([]byte, len(k)-len(pfx)-skip)
+			copy(key, k[len(pfx)+skip:])
+			if !f(bcpb.DataKey(key)) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
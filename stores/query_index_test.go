@@ -0,0 +1,57 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+)
+
+func Test_QueryIndex(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "query-")
+	defer os.RemoveAll(tmpdir)
+
+	db, err := testBadgerDB(tmpdir)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	idx := NewBadgerQueryIndex(db, []byte("query/"))
+
+	outs := map[string]*bcpb.TxOutput{
+		"a": {Tags: map[string]string{"color": "red"}, Labels: []string{"x"}, Metrics: map[string]float64{"size": -2.5}},
+		"b": {Tags: map[string]string{"color": "red"}, Metrics: map[string]float64{"size": 10}},
+		"c": {Tags: map[string]string{"color": "blue"}, Labels: []string{"x"}, Metrics: map[string]float64{"size": 3}},
+	}
+	for k, txo := range outs {
+		assert.Nil(t, idx.Add(nil, bcpb.DataKey(k), txo))
+	}
+
+	collect := func(iter func(DataKeyFunc) error) []string {
+		keys := make([]string, 0)
+		assert.Nil(t, iter(func(key bcpb.DataKey) bool {
+			keys = append(keys, string(key))
+			return true
+		}))
+		return keys
+	}
+
+	red := func(f DataKeyFunc) error { return idx.IterTag("color", "red", f) }
+	x := func(f DataKeyFunc) error { return idx.IterLabel("x", f) }
+	size := func(min, max float64) func(DataKeyFunc) error {
+		return func(f DataKeyFunc) error { return idx.IterMetric("size", min, max, f) }
+	}
+
+	assert.Equal(t, []string{"a", "b"}, collect(red))
+	assert.Equal(t, []string{"a", "c"}, collect(x))
+	assert.Equal(t, []string{"a", "c", "b"}, collect(size(-10, 10)))
+	assert.Equal(t, []string{"c"}, collect(size(0, 9.9)))
+	assert.Equal(t, []string{"a"}, collect(size(-2.5, -2.5)))
+
+	assert.Nil(t, idx.Remove(nil, bcpb.DataKey("a"), outs["a"]))
+	assert.Equal(t, []string{"b"}, collect(red))
+	assert.Equal(t, []string{"c"}, collect(x))
+	assert.Equal(t, []string{"c", "b"}, collect(size(-10, 10)))
+}
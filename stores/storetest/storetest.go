@@ -0,0 +1,927 @@
+// Package storetest provides conformance tests for implementations of the
+// blockchain storage interfaces.  A backend runs them from its own tests with a
+// function returning a new empty store e.g.
+//
+//	func Test_MyBlockStorage(t *testing.T) {
+//		storetest.TestBlockStorage(t, func() blockchain.BlockStorage {
+//			return NewMyBlockStorage()
+//		})
+//	}
+//
+// Each case is run as a subtest against a new store.  TestBatch checks a
+// Batcher against all the stores sharing its database
+package storetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain"
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+	"github.com/hexablock/hasher"
+)
+
+// errStop is returned by iterators to stop early
+var errStop = errors.New("stop")
+
+// testBlock returns a block at the height.  Blocks at different heights have
+// different digests
+func testBlock(height uint32) *bcpb.Block {
+	h := hasher.Default()
+
+	blk := bcpb.NewBlock()
+	blk.Header.Height = height
+	blk.Header.Nonce = uint64(height) + 1
+	blk.Header.Timestamp = int64(height) + 1
+	blk.Header.PrevBlock = bcpb.NewZeroDigest(h)
+	blk.SetHash(h)
+	return blk
+}
+
+func testTx(key string) *bcpb.Tx {
+	tx := bcpb.NewBaseTx()
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key)})
+	tx.SetDigest(hasher.Default())
+	return tx
+}
+
+func testRef(s string) bcpb.Digest {
+	return bcpb.NewDigest(hasher.Default().Name(), []byte(s))
+}
+
+// TestBlockStorage runs the BlockStorage conformance tests.  newStore must
+// return a new empty store each time it is called
+func TestBlockStorage(t *testing.T, newStore func() blockchain.BlockStorage) {
+	cases := []struct {
+		name string
+		test func(*testing.T, blockchain.BlockStorage)
+	}{
+		{"Empty", testBlockStorageEmpty},
+		{"Add", testBlockStorageAdd},
+		{"DuplicateAdd", testBlockStorageDuplicateAdd},
+		{"Pointers", testBlockStoragePointers},
+		{"Weight", testBlockStorageWeight},
+		{"Tips", testBlockStorageTips},
+		{"Certificate", testBlockStorageCertificate},
+		{"Remove", testBlockStorageRemove},
+		{"Iter", testBlockStorageIter},
+		{"Heights", testBlockStorageHeights},
+		{"SignerSets", testBlockStorageSignerSets},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newStore())
+		})
+	}
+}
+
+func testBlockStorageEmpty(t *testing.T, st blockchain.BlockStorage) {
+	id := testBlock(0).Digest
+
+	_, blk := st.Genesis()
+	assert.Nil(t, blk)
+	_, blk = st.Last()
+	assert.Nil(t, blk)
+	_, blk = st.LastExec()
+	assert.Nil(t, blk)
+
+	assert.False(t, st.Exists(id))
+	_, err := st.Get(id)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	_, err = st.Weight(id)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	_, err = st.Certificate(id)
+	assert.Equal(t, stores.ErrCertificateNotFound, err)
+	assert.Equal(t, 0, len(st.Tips()))
+}
+
+func testBlockStorageAdd(t *testing.T, st blockchain.BlockStorage) {
+	blk := testBlock(1)
+
+	id, err := st.Add(nil, blk)
+	assert.Nil(t, err)
+	assert.True(t, st.Exists(id))
+
+	got, err := st.Get(id)
+	assert.Nil(t, err)
+	assert.Equal(t, blk.Header.Height, got.Header.Height)
+	assert.Equal(t, blk.Header.Nonce, got.Header.Nonce)
+	assert.Equal(t, blk.Header.Timestamp, got.Header.Timestamp)
+}
+
+func testBlockStorageDuplicateAdd(t *testing.T, st blockchain.BlockStorage) {
+	blk := testBlock(1)
+
+	id1, err := st.Add(nil, blk)
+	assert.Nil(t, err)
+
+	id2, err := st.Add(nil, blk)
+	assert.Equal(t, stores.ErrBlockExists, err)
+	assert.Equal(t, id1, id2)
+}
+
+func testBlockStoragePointers(t *testing.T, st blockchain.BlockStorage) {
+	ids := make([]bcpb.Digest, 3)
+	for i := range ids {
+		id, err := st.Add(nil, testBlock(uint32(i)))
+		assert.Nil(t, err)
+		ids[i] = id
+	}
+
+	assert.Nil(t, st.SetGenesis(nil, ids[0]))
+	assert.Nil(t, st.SetLast(nil, ids[1]))
+	assert.Nil(t, st.SetLastExec(nil, ids[2]))
+
+	pointers := []func() (bcpb.Digest, *bcpb.Block){st.Genesis, st.Last, st.LastExec}
+	for i, p := range pointers {
+		id, blk := p()
+		assert.Equal(t, ids[i], id)
+		if assert.NotNil(t, blk) {
+			assert.Equal(t, uint32(i), blk.Header.Height)
+		}
+	}
+
+	// Pointers can be moved
+	assert.Nil(t, st.SetLast(nil, ids[2]))
+	id, _ := st.Last()
+	assert.Equal(t, ids[2], id)
+
+	// The executed block must exist
+	assert.Equal(t, stores.ErrBlockNotFound, st.SetLastExec(nil, testBlock(9).Digest))
+	id, _ = st.LastExec()
+	assert.Equal(t, ids[2], id)
+}
+
+func testBlockStorageWeight(t *testing.T, st blockchain.BlockStorage) {
+	id, err := st.Add(nil, testBlock(1))
+	assert.Nil(t, err)
+
+	_, err = st.Weight(id)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+
+	assert.Nil(t, st.SetWeight(nil, id, 42))
+	w, err := st.Weight(id)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), w)
+}
+
+func testBlockStorageTips(t *testing.T, st blockchain.BlockStorage) {
+	ids := make([]bcpb.Digest, 3)
+	for i := range ids {
+		id, err := st.Add(nil, testBlock(uint32(i)))
+		assert.Nil(t, err)
+		assert.Nil(t, st.SetTip(nil, id))
+		ids[i] = id
+	}
+
+	// Tips are returned in key order
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i], ids[j]) < 0 })
+	assert.Equal(t, ids, st.Tips())
+
+	assert.Nil(t, st.RemoveTip(nil, ids[1]))
+	assert.Equal(t, []bcpb.Digest{ids[0], ids[2]}, st.Tips())
+}
+
+func testBlockStorageCertificate(t *testing.T, st blockchain.BlockStorage) {
+	blk := testBlock(1)
+	id, err := st.Add(nil, blk)
+	assert.Nil(t, err)
+
+	cert := bcpb.NewCommitCertificate(blk)
+	assert.Nil(t, st.SetCertificate(nil, id, cert))
+
+	got, err := st.Certificate(id)
+	assert.Nil(t, err)
+	assert.Equal(t, cert.Block, got.Block)
+}
+
+func testBlockStorageRemove(t *testing.T, st blockchain.BlockStorage) {
+	blk := testBlock(1)
+	id, err := st.Add(nil, blk)
+	assert.Nil(t, err)
+	assert.Nil(t, st.SetWeight(nil, id, 1))
+	assert.Nil(t, st.SetTip(nil, id))
+	assert.Nil(t, st.SetCertificate(nil, id, bcpb.NewCommitCertificate(blk)))
+
+	assert.Nil(t, st.Remove(nil, id))
+
+	assert.False(t, st.Exists(id))
+	_, err = st.Get(id)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	_, err = st.Weight(id)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	_, err = st.Certificate(id)
+	assert.Equal(t, stores.ErrCertificateNotFound, err)
+	assert.Equal(t, 0, len(st.Tips()))
+
+	// The block can be added again
+	_, err = st.Add(nil, blk)
+	assert.Nil(t, err)
+}
+
+func testBlockStorageIter(t *testing.T, st blockchain.BlockStorage) {
+	ids := make(map[string]uint32)
+	for i := uint32(0); i < 4; i++ {
+		id, err := st.Add(nil, testBlock(i))
+		assert.Nil(t, err)
+		ids[id.String()] = i
+
+		// Pointers, weights, tips and heights are not blocks
+		assert.Nil(t, st.SetWeight(nil, id, 1))
+		assert.Nil(t, st.SetTip(nil, id))
+		assert.Nil(t, st.SetHeight(nil, i, id))
+		assert.Nil(t, st.SetLast(nil, id))
+	}
+
+	seen := make(map[string]uint32)
+	err := st.Iter(func(id bcpb.Digest, blk *bcpb.Block) error {
+		seen[id.String()] = blk.Header.Height
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ids, seen)
+
+	// Returning an error stops iteration and returns it
+	var c int
+	err = st.Iter(func(bcpb.Digest, *bcpb.Block) error {
+		c++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, c)
+}
+
+func testBlockStorageHeights(t *testing.T, st blockchain.BlockStorage) {
+	_, err := st.GetByHeight(0)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+
+	ids := make([]bcpb.Digest, 5)
+	for i := range ids {
+		id, err := st.Add(nil, testBlock(uint32(i)))
+		assert.Nil(t, err)
+		assert.Nil(t, st.SetHeight(nil, uint32(i), id))
+		ids[i] = id
+	}
+
+	blk, err := st.GetByHeight(3)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[3], blk.Digest)
+	assert.Equal(t, uint32(3), blk.Header.Height)
+
+	collect := func(start uint32, limit int, reverse bool) []uint32 {
+		heights := make([]uint32, 0)
+		err := st.IterHeight(start, limit, reverse, func(id bcpb.Digest, blk *bcpb.Block) error {
+			assert.Equal(t, ids[blk.Header.Height], id)
+			assert.Equal(t, id, blk.Digest)
+			heights = append(heights, blk.Header.Height)
+			return nil
+		})
+		assert.Nil(t, err)
+		return heights
+	}
+
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4}, collect(0, 0, false))
+	assert.Equal(t, []uint32{1, 2}, collect(1, 2, false))
+	assert.Equal(t, []uint32{4, 3, 2, 1, 0}, collect(4, 0, true))
+	assert.Equal(t, []uint32{2, 1}, collect(2, 2, true))
+	assert.Equal(t, []uint32{}, collect(7, 0, false))
+
+	// Returning an error stops iteration and returns it
+	var c int
+	err = st.IterHeight(0, 0, false, func(bcpb.Digest, *bcpb.Block) error {
+		c++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, c)
+
+	// Removing the top height shortens the chain
+	assert.Nil(t, st.RemoveHeight(nil, 4))
+	_, err = st.GetByHeight(4)
+	assert.Equal(t, stores.ErrBlockNotFound, err)
+	assert.Equal(t, []uint32{3, 2, 1, 0}, collect(3, 0, true))
+	assert.Equal(t, []uint32{2, 3}, collect(2, 0, false))
+}
+
+func testBlockStorageSignerSets(t *testing.T, st blockchain.BlockStorage) {
+	_, err := st.SignerSet(10)
+	assert.Equal(t, stores.ErrSignerSetNotFound, err)
+
+	set1 := &bcpb.SignerSet{Signers: []bcpb.PublicKey{bcpb.PublicKey("a")}, N: 1, S: 1, Q: 1}
+	set2 := &bcpb.SignerSet{Signers: []bcpb.PublicKey{bcpb.PublicKey("b")}, N: 1, S: 1, Q: 1}
+	assert.Nil(t, st.SetSignerSet(nil, 1, set1))
+	assert.Nil(t, st.SetSignerSet(nil, 256, set2))
+
+	// The set activated at or below the height is returned
+	_, err = st.SignerSet(0)
+	assert.Equal(t, stores.ErrSignerSetNotFound, err)
+	set, err := st.SignerSet(1)
+	assert.Nil(t, err)
+	assert.Equal(t, set1.Signers, set.Signers)
+	set, _ = st.SignerSet(255)
+	assert.Equal(t, set1.Signers, set.Signers)
+	set, _ = st.SignerSet(1000)
+	assert.Equal(t, set2.Signers, set.Signers)
+
+	assert.Nil(t, st.RemoveSignerSet(nil, 256))
+	set, _ = st.SignerSet(1000)
+	assert.Equal(t, set1.Signers, set.Signers)
+}
+
+// TestTxStorage runs the TxStorage conformance tests.  newStore must return a
+// new empty store each time it is called
+func TestTxStorage(t *testing.T, newStore func() blockchain.TxStorage) {
+	cases := []struct {
+		name string
+		test func(*testing.T, blockchain.TxStorage)
+	}{
+		{"NotFound", testTxStorageNotFound},
+		{"Set", testTxStorageSet},
+		{"SetBatch", testTxStorageSetBatch},
+		{"Remove", testTxStorageRemove},
+		{"Prune", testTxStoragePrune},
+		{"Iter", testTxStorageIter},
+		{"Location", testTxStorageLocation},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newStore())
+		})
+	}
+}
+
+func testTxStorageNotFound(t *testing.T, st blockchain.TxStorage) {
+	_, err := st.Get(testTx("a").Digest)
+	assert.Equal(t, stores.ErrTxNotFound, err)
+}
+
+func testTxStorageSet(t *testing.T, st blockchain.TxStorage) {
+	tx := testTx("a")
+	assert.Nil(t, st.Set(nil, tx))
+
+	got, err := st.Get(tx.Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Digest, got.Digest)
+	if assert.Equal(t, 1, len(got.Outputs)) {
+		assert.Equal(t, bcpb.DataKey("a"), got.Outputs[0].DataKey)
+	}
+}
+
+func testTxStorageSetBatch(t *testing.T, st blockchain.TxStorage) {
+	txs := []*bcpb.Tx{testTx("a"), testTx("b"), testTx("c")}
+	assert.Nil(t, st.SetBatch(nil, txs))
+
+	for _, tx := range txs {
+		got, err := st.Get(tx.Digest)
+		assert.Nil(t, err)
+		assert.Equal(t, tx.Digest, got.Digest)
+	}
+}
+
+func testTxStorageRemove(t *testing.T, st blockchain.TxStorage) {
+	tx := testTx("a")
+	assert.Nil(t, st.Set(nil, tx))
+	assert.Nil(t, st.Remove(nil, tx.Digest))
+
+	_, err := st.Get(tx.Digest)
+	assert.Equal(t, stores.ErrTxNotFound, err)
+}
+
+func testTxStoragePrune(t *testing.T, st blockchain.TxStorage) {
+	txs := []*bcpb.Tx{testTx("a"), testTx("b")}
+	assert.Nil(t, st.SetBatch(nil, txs))
+	assert.Nil(t, st.Prune(nil, txs[0].Digest))
+
+	_, err := st.Get(txs[0].Digest)
+	assert.Equal(t, stores.ErrPruned, err)
+
+	// Pruned txs are skipped
+	var c int
+	st.Iter(func(tx bcpb.Tx) error {
+		assert.Equal(t, txs[1].Digest, tx.Digest)
+		c++
+		return nil
+	})
+	assert.Equal(t, 1, c)
+}
+
+func testTxStorageIter(t *testing.T, st blockchain.TxStorage) {
+	want := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		tx := testTx(fmt.Sprintf("k%d", i))
+		assert.Nil(t, st.Set(nil, tx))
+		want[tx.Digest.String()] = true
+	}
+
+	seen := make(map[string]bool)
+	st.Iter(func(tx bcpb.Tx) error {
+		seen[tx.Digest.String()] = true
+		return nil
+	})
+	assert.Equal(t, want, seen)
+
+	// Returning an error stops iteration
+	var c int
+	st.Iter(func(bcpb.Tx) error {
+		c++
+		return errStop
+	})
+	assert.Equal(t, 1, c)
+}
+
+func testTxStorageLocation(t *testing.T, st blockchain.TxStorage) {
+	tx := testTx("a")
+	_, err := st.Location(tx.Digest)
+	assert.Equal(t, stores.ErrLocationNotFound, err)
+
+	loc := stores.TxLocation{Block: testRef("blk"), Height: 7, Index: 2}
+	assert.Nil(t, st.Set(nil, tx))
+	assert.Nil(t, st.SetLocation(nil, tx.Digest, loc))
+
+	got, err := st.Location(tx.Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, loc, got)
+
+	// Locations are not iterated as txs and survive pruning
+	var c int
+	st.Iter(func(bcpb.Tx) error {
+		c++
+		return nil
+	})
+	assert.Equal(t, 1, c)
+
+	assert.Nil(t, st.Prune(nil, tx.Digest))
+	_, err = st.Location(tx.Digest)
+	assert.Nil(t, err)
+
+	assert.Nil(t, st.RemoveLocation(nil, tx.Digest))
+	_, err = st.Location(tx.Digest)
+	assert.Equal(t, stores.ErrLocationNotFound, err)
+}
+
+// TestDataKeyIndex runs the DataKeyIndex conformance tests.  newIndex must
+// return a new empty index each time it is called
+func TestDataKeyIndex(t *testing.T, newIndex func() blockchain.DataKeyIndex) {
+	cases := []struct {
+		name string
+		test func(*testing.T, blockchain.DataKeyIndex)
+	}{
+		{"NotFound", testDataKeyIndexNotFound},
+		{"Set", testDataKeyIndexSet},
+		{"Delete", testDataKeyIndexDelete},
+		{"Remove", testDataKeyIndexRemove},
+		{"Iter", testDataKeyIndexIter},
+		{"Undo", testDataKeyIndexUndo},
+		{"History", testDataKeyIndexHistory},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newIndex())
+		})
+	}
+}
+
+func testDataKeyIndexNotFound(t *testing.T, idx blockchain.DataKeyIndex) {
+	_, _, err := idx.Get(bcpb.DataKey("missing"))
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+}
+
+func testDataKeyIndexSet(t *testing.T, idx blockchain.DataKeyIndex) {
+	key := bcpb.DataKey("key")
+	assert.Nil(t, idx.Set(nil, key, testRef("a"), 1))
+
+	ref, i, err := idx.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, testRef("a"), ref)
+	assert.Equal(t, int32(1), i)
+
+	// Set overwrites
+	assert.Nil(t, idx.Set(nil, key, testRef("b"), 0))
+	ref, i, err = idx.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, testRef("b"), ref)
+	assert.Equal(t, int32(0), i)
+}
+
+func testDataKeyIndexDelete(t *testing.T, idx blockchain.DataKeyIndex) {
+	key := bcpb.DataKey("key")
+	assert.Nil(t, idx.Set(nil, key, testRef("a"), 0))
+	assert.Nil(t, idx.Delete(nil, key, testRef("d")))
+
+	ref, i, err := idx.Get(key)
+	assert.Equal(t, stores.ErrDataKeyDeleted, err)
+	assert.Equal(t, testRef("d"), ref)
+	assert.Equal(t, stores.TombstoneIndex, i)
+
+	// Deleted keys can be set again
+	assert.Nil(t, idx.Set(nil, key, testRef("b"), 0))
+	_, _, err = idx.Get(key)
+	assert.Nil(t, err)
+}
+
+func testDataKeyIndexRemove(t *testing.T, idx blockchain.DataKeyIndex) {
+	key := bcpb.DataKey("key")
+	assert.Nil(t, idx.Set(nil, key, testRef("a"), 0))
+	assert.Nil(t, idx.Remove(nil, key))
+
+	_, _, err := idx.Get(key)
+	assert.Equal(t, stores.ErrDataKeyNotFound, err)
+}
+
+func testDataKeyIndexIter(t *testing.T, idx blockchain.DataKeyIndex) {
+	keys := []string{"b:2", "a:1", "b:1", "c:1", "b:3"}
+	for i, k := range keys {
+		assert.Nil(t, idx.Set(nil, bcpb.DataKey(k), testRef(k), int32(i)))
+	}
+	assert.Nil(t, idx.Delete(nil, bcpb.DataKey("b:3"), testRef("d")))
+
+	collect := func(prefix string, limit int) []string {
+		out := make([]string, 0)
+		err := idx.Iter(bcpb.DataKey(prefix), func(k bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+			assert.Equal(t, testRef(string(k)), ref)
+			out = append(out, string(k))
+			return len(out) != limit
+		})
+		assert.Nil(t, err)
+		return out
+	}
+
+	// All keys in key order skipping deleted ones
+	assert.Equal(t, []string{"a:1", "b:1", "b:2", "c:1"}, collect("", 0))
+	// Only keys with the prefix
+	assert.Equal(t, []string{"b:1", "b:2"}, collect("b:", 0))
+	assert.Equal(t, []string{}, collect("d:", 0))
+	// Returning false stops iteration
+	assert.Equal(t, []string{"a:1", "b:1"}, collect("", 2))
+
+	// IterAll includes deleted keys with their tombstone
+	var all []string
+	err := idx.IterAll(bcpb.DataKey("b:"), func(k bcpb.DataKey, ref bcpb.Digest, i int32) bool {
+		if i == stores.TombstoneIndex {
+			assert.Equal(t, testRef("d"), ref)
+		}
+		all = append(all, fmt.Sprintf("%s/%d", k, i))
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b:1/2", "b:2/0", "b:3/-1"}, all)
+}
+
+func testDataKeyIndexUndo(t *testing.T, idx blockchain.DataKeyIndex) {
+	block := testRef("block")
+
+	_, err := idx.Undo(block)
+	assert.NotNil(t, err)
+
+	entries := []stores.UndoEntry{
+		{Key: bcpb.DataKey("new")},
+		{Key: bcpb.DataKey("set"), Ref: testRef("a"), Index: 2},
+		{Key: bcpb.DataKey("deleted"), Ref: testRef("d"), Index: stores.TombstoneIndex},
+	}
+	assert.Nil(t, idx.SetUndo(nil, block, entries))
+
+	got, err := idx.Undo(block)
+	assert.Nil(t, err)
+	assert.Equal(t, entries, got)
+	assert.True(t, got[2].Deleted())
+
+	assert.Nil(t, idx.RemoveUndo(nil, block))
+	_, err = idx.Undo(block)
+	assert.NotNil(t, err)
+}
+
+func testDataKeyIndexHistory(t *testing.T, idx blockchain.DataKeyIndex) {
+	key := bcpb.DataKey("key")
+
+	// Added out of order across heights and sequences
+	entries := []stores.HistoryEntry{
+		{Height: 2, Seq: 1, Timestamp: 20, Ref: testRef("c"), Index: 0},
+		{Height: 1, Seq: 0, Timestamp: 10, Ref: testRef("a"), Index: 0},
+		{Height: 2, Seq: 0, Timestamp: 20, Ref: testRef("b"), Index: 1},
+		{Height: 3, Seq: 0, Timestamp: 30, Ref: testRef("d"), Index: stores.TombstoneIndex},
+	}
+	for _, e := range entries {
+		assert.Nil(t, idx.AddHistory(nil, key, e))
+	}
+	// A key sharing the prefix has its own history
+	assert.Nil(t, idx.AddHistory(nil, bcpb.DataKey("key2"), entries[0]))
+
+	collect := func(limit int) []stores.HistoryEntry {
+		out := make([]stores.HistoryEntry, 0)
+		err := idx.History(key, func(e stores.HistoryEntry) bool {
+			out = append(out, e)
+			return len(out) != limit
+		})
+		assert.Nil(t, err)
+		return out
+	}
+
+	// In the order written
+	want := []stores.HistoryEntry{entries[1], entries[2], entries[0], entries[3]}
+	assert.Equal(t, want, collect(0))
+	assert.True(t, collect(0)[3].Deleted())
+	// Returning false stops iteration
+	assert.Equal(t, want[:1], collect(1))
+
+	// Removing a height removes all entries at it
+	assert.Nil(t, idx.RemoveHistory(nil, key, 2))
+	assert.Equal(t, []stores.HistoryEntry{entries[1], entries[3]}, collect(0))
+}
+
+// TestUTXOIndex runs the UTXOIndex conformance tests.  newIndex must return a
+// new empty index using the default hasher each time it is called
+func TestUTXOIndex(t *testing.T, newIndex func() blockchain.UTXOIndex) {
+	cases := []struct {
+		name string
+		test func(*testing.T, blockchain.UTXOIndex)
+	}{
+		{"Add", testUTXOIndexAdd},
+		{"Remove", testUTXOIndexRemove},
+		{"IterAddress", testUTXOIndexIterAddress},
+		{"Iter", testUTXOIndexIter},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newIndex())
+		})
+	}
+}
+
+func testUTXOIndexAdd(t *testing.T, idx blockchain.UTXOIndex) {
+	ref := testRef("a")
+	assert.False(t, idx.Exists(ref, 0))
+
+	assert.Nil(t, idx.Add(nil, ref, 0, &bcpb.TxOutput{}))
+	assert.True(t, idx.Exists(ref, 0))
+	assert.False(t, idx.Exists(ref, 1))
+	assert.False(t, idx.Exists(testRef("b"), 0))
+}
+
+func testUTXOIndexRemove(t *testing.T, idx blockchain.UTXOIndex) {
+	ref := testRef("a")
+	txo := &bcpb.TxOutput{PubKeys: []bcpb.PublicKey{bcpb.PublicKey("key1")}}
+	assert.Nil(t, idx.Add(nil, ref, 0, txo))
+	assert.Nil(t, idx.Add(nil, ref, 1, txo))
+
+	// Outputs of the same tx are tracked individually
+	assert.Nil(t, idx.Remove(nil, ref, 0, txo))
+	assert.False(t, idx.Exists(ref, 0))
+	assert.True(t, idx.Exists(ref, 1))
+
+	var c int
+	idx.IterAddress(txo.PubKeys[0].Address(hasher.Default()), nil, func(bcpb.Digest, int32) bool {
+		c++
+		return true
+	})
+	assert.Equal(t, 1, c)
+}
+
+func testUTXOIndexIterAddress(t *testing.T, idx blockchain.UTXOIndex) {
+	h := hasher.Default()
+	ref := testRef("a")
+	pk1, pk2 := bcpb.PublicKey("key1"), bcpb.PublicKey("key2")
+
+	assert.Nil(t, idx.Add(nil, ref, 2, &bcpb.TxOutput{PubKeys: []bcpb.PublicKey{pk1}}))
+	assert.Nil(t, idx.Add(nil, ref, 0, &bcpb.TxOutput{PubKeys: []bcpb.PublicKey{pk1, pk2}}))
+	assert.Nil(t, idx.Add(nil, ref, 1, &bcpb.TxOutput{}))
+
+	list := func(pk bcpb.PublicKey, after []byte, limit int) []int32 {
+		out := make([]int32, 0)
+		err := idx.IterAddress(pk.Address(h), after, func(r bcpb.Digest, i int32) bool {
+			assert.Equal(t, ref, r)
+			out = append(out, i)
+			return len(out) != limit
+		})
+		assert.Nil(t, err)
+		return out
+	}
+
+	// In OutPoint order
+	assert.Equal(t, []int32{0, 2}, list(pk1, nil, 0))
+	assert.Equal(t, []int32{0}, list(pk2, nil, 0))
+	assert.Equal(t, []int32{}, list(bcpb.PublicKey("key3"), nil, 0))
+	// Starting after an OutPoint
+	assert.Equal(t, []int32{2}, list(pk1, stores.OutPoint(ref, 0), 0))
+	// Returning false stops iteration
+	assert.Equal(t, []int32{0}, list(pk1, nil, 1))
+}
+
+func testUTXOIndexIter(t *testing.T, idx blockchain.UTXOIndex) {
+	for _, i := range []int32{3, 0, 1} {
+		assert.Nil(t, idx.Add(nil, testRef("a"), i, &bcpb.TxOutput{}))
+	}
+
+	collect := func(limit int) []int32 {
+		out := make([]int32, 0)
+		err := idx.Iter(func(ref bcpb.Digest, i int32) bool {
+			assert.Equal(t, testRef("a"), ref)
+			out = append(out, i)
+			return len(out) != limit
+		})
+		assert.Nil(t, err)
+		return out
+	}
+
+	assert.Equal(t, []int32{0, 1, 3}, collect(0))
+	assert.Equal(t, []int32{0, 1}, collect(2))
+}
+
+// TestQueryIndex runs the QueryIndex conformance tests.  newIndex must return a
+// new empty index each time it is called
+func TestQueryIndex(t *testing.T, newIndex func() blockchain.QueryIndex) {
+	cases := []struct {
+		name string
+		test func(*testing.T, blockchain.QueryIndex)
+	}{
+		{"Tag", testQueryIndexTag},
+		{"Label", testQueryIndexLabel},
+		{"Metric", testQueryIndexMetric},
+		{"Remove", testQueryIndexRemove},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			idx := newIndex()
+			for _, k := range []string{"a", "b", "c"} {
+				assert.Nil(t, idx.Add(nil, bcpb.DataKey(k), testQueryOutputs[k]))
+			}
+			c.test(t, idx)
+		})
+	}
+}
+
+// testQueryOutputs is added to each QueryIndex before a case is run
+var testQueryOutputs = map[string]*bcpb.TxOutput{
+	"a": {Tags: map[string]string{"color": "red"}, Labels: []string{"x"}, Metrics: map[string]float64{"size": -2.5}},
+	"b": {Tags: map[string]string{"color": "red"}, Metrics: map[string]float64{"size": 10}},
+	"c": {Tags: map[string]string{"color": "blue"}, Labels: []string{"x", "y"}, Metrics: map[string]float64{"size": 3}},
+}
+
+// collectKeys returns the DataKeys passed by iter.  limit stops iteration once
+// reached if not 0
+func collectKeys(t *testing.T, iter func(stores.DataKeyFunc) error, limit int) []string {
+	keys := make([]string, 0)
+	assert.Nil(t, iter(func(key bcpb.DataKey) bool {
+		keys = append(keys, string(key))
+		return len(keys) != limit
+	}))
+	return keys
+}
+
+func testQueryIndexTag(t *testing.T, idx blockchain.QueryIndex) {
+	tag := func(name, value string) func(stores.DataKeyFunc) error {
+		return func(f stores.DataKeyFunc) error { return idx.IterTag(name, value, f) }
+	}
+
+	assert.Equal(t, []string{"a", "b"}, collectKeys(t, tag("color", "red"), 0))
+	assert.Equal(t, []string{"c"}, collectKeys(t, tag("color", "blue"), 0))
+	assert.Equal(t, []string{}, collectKeys(t, tag("color", "re"), 0))
+	assert.Equal(t, []string{}, collectKeys(t, tag("colo", "rred"), 0))
+	assert.Equal(t, []string{"a"}, collectKeys(t, tag("color", "red"), 1))
+}
+
+func testQueryIndexLabel(t *testing.T, idx blockchain.QueryIndex) {
+	label := func(l string) func(stores.DataKeyFunc) error {
+		return func(f stores.DataKeyFunc) error { return idx.IterLabel(l, f) }
+	}
+
+	assert.Equal(t, []string{"a", "c"}, collectKeys(t, label("x"), 0))
+	assert.Equal(t, []string{"c"}, collectKeys(t, label("y"), 0))
+	assert.Equal(t, []string{}, collectKeys(t, label("z"), 0))
+}
+
+func testQueryIndexMetric(t *testing.T, idx blockchain.QueryIndex) {
+	size := func(min, max float64) func(stores.DataKeyFunc) error {
+		return func(f stores.DataKeyFunc) error { return idx.IterMetric("size", min, max, f) }
+	}
+
+	// In value order with inclusive bounds
+	assert.Equal(t, []string{"a", "c", "b"}, collectKeys(t, size(-10, 10), 0))
+	assert.Equal(t, []string{"c"}, collectKeys(t, size(0, 9.9), 0))
+	assert.Equal(t, []string{"a"}, collectKeys(t, size(-2.5, -2.5), 0))
+	assert.Equal(t, []string{}, collectKeys(t, size(11, 20), 0))
+	assert.Equal(t, []string{"a", "c"}, collectKeys(t, size(-10, 10), 2))
+}
+
+func testQueryIndexRemove(t *testing.T, idx blockchain.QueryIndex) {
+	assert.Nil(t, idx.Remove(nil, bcpb.DataKey("a"), testQueryOutputs["a"]))
+
+	red := func(f stores.DataKeyFunc) error { return idx.IterTag("color", "red", f) }
+	x := func(f stores.DataKeyFunc) error { return idx.IterLabel("x", f) }
+	size := func(f stores.DataKeyFunc) error { return idx.IterMetric("size", -10, 10, f) }
+
+	assert.Equal(t, []string{"b"}, collectKeys(t, red, 0))
+	assert.Equal(t, []string{"c"}, collectKeys(t, x, 0))
+	assert.Equal(t, []string{"c", "b"}, collectKeys(t, size, 0))
+
+	// Outputs without Tags, Labels or Metrics are not indexed
+	assert.Nil(t, idx.Add(nil, bcpb.DataKey("d"), &bcpb.TxOutput{}))
+	assert.Nil(t, idx.Remove(nil, bcpb.DataKey("d"), &bcpb.TxOutput{}))
+}
+
+// Stores holds a Batcher and one store of each kind sharing its database
+type Stores struct {
+	Batcher blockchain.Batcher
+	Block   blockchain.BlockStorage
+	Tx      blockchain.TxStorage
+	DataKey blockchain.DataKeyIndex
+	UTXO    blockchain.UTXOIndex
+	Query   blockchain.QueryIndex
+}
+
+// TestBatch runs the Batch conformance tests writing to all stores through a
+// single batch.  newStores must return new empty stores each time it is called
+func TestBatch(t *testing.T, newStores func() Stores) {
+	cases := []struct {
+		name string
+		test func(*testing.T, Stores)
+	}{
+		{"Commit", testBatchCommit},
+		{"Discard", testBatchDiscard},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newStores())
+		})
+	}
+}
+
+// testBatchOutput is written to the indexes by testBatchWrite
+var testBatchOutput = &bcpb.TxOutput{
+	DataKey: bcpb.DataKey("a"),
+	PubKeys: []bcpb.PublicKey{bcpb.PublicKey("key1")},
+	Labels:  []string{"x"},
+}
+
+// testBatchWrite writes a block, a tx and its output to the stores through the
+// batch and returns the block and tx
+func testBatchWrite(t *testing.T, s Stores, batch stores.Batch) (*bcpb.Block, *bcpb.Tx) {
+	blk := testBlock(1)
+	tx := testTx("a")
+
+	_, err := s.Block.Add(batch, blk)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Tx.Set(batch, tx))
+	assert.Nil(t, s.DataKey.Set(batch, testBatchOutput.DataKey, tx.Digest, 0))
+	assert.Nil(t, s.UTXO.Add(batch, tx.Digest, 0, testBatchOutput))
+	assert.Nil(t, s.Query.Add(batch, testBatchOutput.DataKey, testBatchOutput))
+
+	return blk, tx
+}
+
+// testBatchVisible checks whether the writes of testBatchWrite are visible in
+// each of the stores
+func testBatchVisible(t *testing.T, s Stores, blk *bcpb.Block, tx *bcpb.Tx, visible bool) {
+	assert.Equal(t, visible, s.Block.Exists(blk.Digest))
+
+	_, err := s.Tx.Get(tx.Digest)
+	assert.Equal(t, visible, err == nil)
+
+	_, _, err = s.DataKey.Get(testBatchOutput.DataKey)
+	assert.Equal(t, visible, err == nil)
+
+	assert.Equal(t, visible, s.UTXO.Exists(tx.Digest, 0))
+	var n int
+	s.UTXO.IterAddress(testBatchOutput.PubKeys[0].Address(hasher.Default()), nil, func(bcpb.Digest, int32) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, visible, n == 1)
+
+	label := func(f stores.DataKeyFunc) error { return s.Query.IterLabel("x", f) }
+	assert.Equal(t, visible, len(collectKeys(t, label, 0)) == 1)
+}
+
+func testBatchCommit(t *testing.T, s Stores) {
+	batch := s.Batcher.NewBatch()
+	defer batch.Discard()
+
+	// Writes are not visible until the batch is committed
+	blk, tx := testBatchWrite(t, s, batch)
+	testBatchVisible(t, s, blk, tx, false)
+
+	assert.Nil(t, batch.Commit())
+	testBatchVisible(t, s, blk, tx, true)
+}
+
+func testBatchDiscard(t *testing.T, s Stores) {
+	batch := s.Batcher.NewBatch()
+	blk, tx := testBatchWrite(t, s, batch)
+
+	// Discarded writes are dropped and the batch cannot be committed
+	batch.Discard()
+	testBatchVisible(t, s, blk, tx, false)
+	assert.NotNil(t, batch.Commit())
+	testBatchVisible(t, s, blk, tx, false)
+}
@@ -2,6 +2,7 @@ package stores
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"log"
 
@@ -14,32 +15,78 @@ import (
 const (
 	// Transaction key sub prefix
 	txSubkeyPrefix = "tx/"
+	// Transaction location key sub prefix
+	txLocSubkeyPrefix = "txloc/"
 )
 
 var (
-	errTxExists   = errors.New("tx exists")
-	errTxNotFound = errors.New("tx not found")
+	// ErrTxNotFound is returned when getting a tx that is not in the store
+	ErrTxNotFound = errors.New("tx not found")
+	// ErrPruned is returned when getting a tx whose body has been pruned
+	ErrPruned = errors.New("tx pruned")
+	// ErrLocationNotFound is returned when getting the location of a tx that is
+	// not in a committed block
+	ErrLocationNotFound = errors.New("tx location not found")
+
+	errTxExists          = errors.New("tx exists")
+	errInvalidTxLocation = errors.New("invalid tx location")
 )
 
+// TxLocation is the position of a tx on the main chain
+type TxLocation struct {
+	// Block containing the tx and its height
+	Block  bcpb.Digest
+	Height uint32
+	// Index of the tx in the block
+	Index int32
+}
+
+func encodeTxLocation(loc TxLocation) []byte {
+	val := make([]byte, 8, 8+len(loc.Block))
+	binary.BigEndian.PutUint32(val, loc.Height)
+	binary.BigEndian.PutUint32(val[4:], uint32(loc.Index))
+	return append(val, loc.Block...)
+}
+
+func decodeTxLocation(val []byte) (TxLocation, error) {
+	var loc TxLocation
+	if len(val) < 8 {
+		return loc, errInvalidTxLocation
+	}
+
+	loc.Height = binary.BigEndian.Uint32(val)
+	loc.Index = int32(binary.BigEndian.Uint32(val[4:]))
+	loc.Block = bcpb.Digest(val[8:])
+
+	return loc, nil
+}
+
 // BadgerTxStorage implements a badger backed TxStorage interface
 type BadgerTxStorage struct {
-	prefix []byte
-	db     *badger.DB
+	prefix    []byte
+	locPrefix []byte
+	db        *badger.DB
 }
 
 // NewBadgerTxStorage returns a new badger backed tx storage device.
 func NewBadgerTxStorage(db *badger.DB, keyPrefix []byte) *BadgerTxStorage {
 	return &BadgerTxStorage{
-		prefix: append(keyPrefix, []byte(txSubkeyPrefix)...),
-		db:     db,
+		prefix:    concatKey(keyPrefix, []byte(txSubkeyPrefix)),
+		locPrefix: concatKey(keyPrefix, []byte(txLocSubkeyPrefix)),
+		db:        db,
 	}
 }
 
 func (store *BadgerTxStorage) getkey(key []byte) []byte {
-	return append(store.prefix, key...)
+	return concatKey(store.prefix, key)
 }
 
-// Get retrieves a transaction by the given id
+func (store *BadgerTxStorage) locKey(id bcpb.Digest) []byte {
+	return concatKey(store.locPrefix, id)
+}
+
+// Get retrieves a transaction by the given id.  It returns ErrTxNotFound if the
+// transaction does not exist and ErrPruned if it has been pruned
 func (store *BadgerTxStorage) Get(id bcpb.Digest) (*bcpb.Tx, error) {
 	var (
 		key = store.getkey(id)
@@ -49,6 +96,9 @@ func (store *BadgerTxStorage) Get(id bcpb.Digest) (*bcpb.Tx, error) {
 	err := store.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = ErrTxNotFound
+			}
 			return err
 		}
 		val, err = item.ValueCopy(nil)
@@ -58,6 +108,9 @@ func (store *BadgerTxStorage) Get(id bcpb.Digest) (*bcpb.Tx, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(val) == 0 {
+		return nil, ErrPruned
+	}
 
 	var tx bcpb.Tx
 	err = proto.Unmarshal(val, &tx)
@@ -66,7 +119,7 @@ func (store *BadgerTxStorage) Get(id bcpb.Digest) (*bcpb.Tx, error) {
 }
 
 // Iter iterates over each transaction, calling the f for each encountered
-// tx.
+// tx.  Pruned transactions are skipped
 func (store *BadgerTxStorage) Iter(f func(bcpb.Tx) error) {
 	store.db.View(func(txn *badger.Txn) error {
 		iter := txn.NewIterator(badger.DefaultIteratorOptions)
@@ -85,6 +138,9 @@ func (store *BadgerTxStorage) Iter(f func(bcpb.Tx) error) {
 				log.Printf("[ERR] Failed to get value key=%q: %v", key, err)
 				continue
 			}
+			if len(val) == 0 {
+				continue
+			}
 
 			var tx bcpb.Tx
 			err = proto.Unmarshal(val, &tx)
@@ -101,23 +157,25 @@ func (store *BadgerTxStorage) Iter(f func(bcpb.Tx) error) {
 	})
 }
 
-// Set sets the transaction returning the hash id of the transaction
-func (store *BadgerTxStorage) Set(tx *bcpb.Tx) error {
+// Set sets the transaction as part of the batch.  A nil batch writes the
+// transaction immediately
+func (store *BadgerTxStorage) Set(batch Batch, tx *bcpb.Tx) error {
 	b, err := proto.Marshal(tx)
 	if err != nil {
 		return err
 	}
 
 	key := store.getkey(tx.Digest)
-	err = store.db.Update(func(txn *badger.Txn) error {
+	err = badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
 		return txn.Set(key, b)
 	})
 
 	return err
 }
 
-// SetBatch sets a batch of transactions returning each id or an error
-func (store *BadgerTxStorage) SetBatch(txs []*bcpb.Tx) error {
+// SetBatch sets a batch of transactions as part of the write batch returning
+// an error if any one fails
+func (store *BadgerTxStorage) SetBatch(batch Batch, txs []*bcpb.Tx) error {
 	var (
 		l      = len(txs)
 		keys   = make([]bcpb.Digest, l)
@@ -135,7 +193,7 @@ func (store *BadgerTxStorage) SetBatch(txs []*bcpb.Tx) error {
 
 	}
 
-	err = store.db.Update(func(txn *badger.Txn) error {
+	err = badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
 		for i := 0; i < l; i++ {
 			if er := txn.Set(keys[i], values[i]); er != nil {
 				return er
@@ -146,3 +204,60 @@ func (store *BadgerTxStorage) SetBatch(txs []*bcpb.Tx) error {
 
 	return err
 }
+
+// Remove removes the transaction by the given id
+func (store *BadgerTxStorage) Remove(batch Batch, id bcpb.Digest) error {
+	key := store.getkey(id)
+	return badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// Prune removes the body of the transaction by the given id.  An empty value is
+// kept in its place so Get can tell a pruned transaction from a missing one
+func (store *BadgerTxStorage) Prune(batch Batch, id bcpb.Digest) error {
+	key := store.getkey(id)
+	return badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, nil)
+	})
+}
+
+// Location returns the main chain location of the transaction by the given id
+// or ErrLocationNotFound
+func (store *BadgerTxStorage) Location(id bcpb.Digest) (TxLocation, error) {
+	var val []byte
+	err := store.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(store.locKey(id))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				err = ErrLocationNotFound
+			}
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if err != nil {
+		return TxLocation{}, err
+	}
+	return decodeTxLocation(val)
+}
+
+// SetLocation sets the main chain location of the transaction by the given id
+func (store *BadgerTxStorage) SetLocation(batch Batch, id bcpb.Digest, loc TxLocation) error {
+	key := store.locKey(id)
+	val := encodeTxLocation(loc)
+	return badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
+		return txn.Set(key, val)
+	})
+}
+
+// RemoveLocation removes the main chain location of the transaction by the
+// given id
+func (store *BadgerTxStorage) RemoveLocation(batch Batch, id bcpb.Digest) error {
+	key := store.locKey(id)
+	return badgerUpdate(store.db, batch, func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
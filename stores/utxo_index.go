@@ -0,0 +1,200 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+const (
+	// Unspent output key sub prefix
+	utxoSubkeyPrefix = "utxo/"
+	// Address to unspent output key sub prefix
+	addrSubkeyPrefix = "addr/"
+)
+
+// UTXOIterator is used to iterate over unspent outputs
+type UTXOIterator func(ref bcpb.Digest, idx int32) bool
+
+// utxoKeyspace holds the key layout of a UTXOIndex.  Each unspent output is
+// keyed by its OutPoint.  The outputs are also indexed by the address of each
+// public key able to unlock them
+type utxoKeyspace struct {
+	prefix     []byte
+	addrPrefix []byte
+	hasher     hasher.Hasher
+}
+
+func newUTXOKeyspace(prefix []byte, h hasher.Hasher) utxoKeyspace {
+	return utxoKeyspace{
+		prefix:     concatKey(prefix, []byte(utxoSubkeyPrefix)),
+		addrPrefix: concatKey(prefix, []byte(addrSubkeyPrefix)),
+		hasher:     h,
+	}
+}
+
+// BadgerUTXOIndex implements the UTXOIndex interface backed by badger
+// key-value store.  Each unspent output is keyed by its tx digest followed by
+// the big endian output index.  The outputs are also indexed by the address of
+// each public key able to unlock them
+type BadgerUTXOIndex struct {
+	db *badger.DB
+	utxoKeyspace
+}
+
+// NewBadgerUTXOIndex inits a new BadgerUTXOIndex.  The hasher is used to
+// compute public key addresses
+func NewBadgerUTXOIndex(db *badger.DB, prefix []byte, h hasher.Hasher) *BadgerUTXOIndex {
+	return &BadgerUTXOIndex{
+		db:           db,
+		utxoKeyspace: newUTXOKeyspace(prefix, h),
+	}
+}
+
+// OutPoint returns the key of an output i.e. the tx digest followed by the big
+// endian output index
+func OutPoint(ref bcpb.Digest, idx int32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(idx))
+	return concatKey(ref, b)
+}
+
+// parseOutPoint returns the tx digest and output index in the key
+func parseOutPoint(key []byte) (bcpb.Digest, int32, bool) {
+	if len(key) < 4 {
+		return nil, 0, false
+	}
+	l := len(key) - 4
+	ref := make([]byte, l)
+	copy(ref, key[:l])
+	return bcpb.Digest(ref), int32(binary.BigEndian.Uint32(key[l:])), true
+}
+
+func (ks utxoKeyspace) getkey(ref bcpb.Digest, idx int32) []byte {
+	return concatKey(ks.prefix, OutPoint(ref, idx))
+}
+
+func (ks utxoKeyspace) getAddrPrefix(addr []byte) []byte {
+	return concatKey(ks.addrPrefix, addr, []byte("/"))
+}
+
+// addrKeys returns the address index keys of the output
+func (ks utxoKeyspace) addrKeys(ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) [][]byte {
+	var (
+		op   = OutPoint(ref, idx)
+		keys = make([][]byte, 0, len(txo.PubKeys))
+	)
+
+	for _, pk := range txo.PubKeys {
+		addr := pk.Address(ks.hasher)
+		keys = append(keys, concatKey(ks.getAddrPrefix(addr), op))
+	}
+	return keys
+}
+
+// Exists returns true if the output is unspent
+func (index *BadgerUTXOIndex) Exists(ref bcpb.Digest, idx int32) bool {
+	k := index.getkey(ref, idx)
+
+	err := index.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(k)
+		return err
+	})
+
+	return err == nil
+}
+
+// Add marks the output as unspent and indexes it by the address of each of its
+// public keys
+func (index *BadgerUTXOIndex) Add(batch Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error {
+	k := index.getkey(ref, idx)
+	keys := index.addrKeys(ref, idx, txo)
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		for _, ak := range keys {
+			if err := txn.Set(ak, nil); err != nil {
+				return err
+			}
+		}
+		return txn.Set(k, nil)
+	})
+}
+
+// Remove marks the output as spent removing it from the address index
+func (index *BadgerUTXOIndex) Remove(batch Batch, ref bcpb.Digest, idx int32, txo *bcpb.TxOutput) error {
+	k := index.getkey(ref, idx)
+	keys := index.addrKeys(ref, idx, txo)
+
+	return badgerUpdate(index.db, batch, func(txn *badger.Txn) error {
+		for _, ak := range keys {
+			if err := txn.Delete(ak); err != nil {
+				return err
+			}
+		}
+		return txn.Delete(k)
+	})
+}
+
+// IterAddress iterates over the unspent outputs the address can unlock in
+// OutPoint order.  If after is not nil iteration starts after that OutPoint
+func (index *BadgerUTXOIndex) IterAddress(addr []byte, after []byte, f UTXOIterator) error {
+	pfx := index.getAddrPrefix(addr)
+	start := concatKey(pfx, after)
+
+	return index.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(start); iter.Valid(); iter.Next() {
+			key := iter.Item().Key()
+			if !bytes.HasPrefix(key, pfx) {
+				break
+			}
+			if after != nil && bytes.Equal(key, start) {
+				continue
+			}
+
+			ref, idx, ok := parseOutPoint(key[len(pfx):])
+			if !ok {
+				continue
+			}
+
+			if !f(ref, idx) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+// Iter iterates over all unspent outputs in OutPoint order
+func (index *BadgerUTXOIndex) Iter(f UTXOIterator) error {
+	pfx := index.prefix
+
+	return index.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(pfx); iter.Valid(); iter.Next() {
+			key := iter.Item().Key()
+			if !bytes.HasPrefix(key, pfx) {
+				break
+			}
+
+			ref, idx, ok := parseOutPoint(key[len(pfx):])
+			if !ok {
+				continue
+			}
+
+			if !f(ref, idx) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
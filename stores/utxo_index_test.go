@@ -0,0 +1,64 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/hasher"
+)
+
+func Test_UTXOIndex(t *testing.T) {
+	tmpdir, _ := ioutil.TempDir("/tmp", "utxo-")
+	defer os.RemoveAll(tmpdir)
+
+	db, err := testBadgerDB(tmpdir)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	h := hasher.Default()
+	idx := NewBadgerUTXOIndex(db, []byte("utxo/"), h)
+	z := bcpb.NewZeroDigest(h)
+
+	pk1, pk2 := bcpb.PublicKey("key1"), bcpb.PublicKey("key2")
+	txo := &bcpb.TxOutput{PubKeys: []bcpb.PublicKey{pk1, pk2}}
+
+	assert.False(t, idx.Exists(z, 0))
+	assert.Nil(t, idx.Add(nil, z, 0, txo))
+	assert.Nil(t, idx.Add(nil, z, 1, &bcpb.TxOutput{PubKeys: []bcpb.PublicKey{pk1}}))
+	assert.Nil(t, idx.Add(nil, z, 2, &bcpb.TxOutput{}))
+	assert.True(t, idx.Exists(z, 0))
+	assert.True(t, idx.Exists(z, 1))
+	assert.False(t, idx.Exists(z, 3))
+
+	list := func(pk bcpb.PublicKey, after []byte) []int32 {
+		out := make([]int32, 0)
+		idx.IterAddress(pk.Address(h), after, func(ref bcpb.Digest, i int32) bool {
+			assert.Equal(t, z, ref)
+			out = append(out, i)
+			return true
+		})
+		return out
+	}
+
+	assert.Equal(t, []int32{0, 1}, list(pk1, nil))
+	assert.Equal(t, []int32{1}, list(pk1, OutPoint(z, 0)))
+	assert.Equal(t, []int32{0}, list(pk2, nil))
+
+	all := make([]int32, 0)
+	assert.Nil(t, idx.Iter(func(ref bcpb.Digest, i int32) bool {
+		all = append(all, i)
+		return true
+	}))
+	assert.Equal(t, []int32{0, 1, 2}, all)
+
+	// Outputs of the same tx are tracked individually
+	assert.Nil(t, idx.Remove(nil, z, 0, txo))
+	assert.False(t, idx.Exists(z, 0))
+	assert.True(t, idx.Exists(z, 1))
+	assert.Equal(t, []int32{1}, list(pk1, nil))
+	assert.Equal(t, []int32{}, list(pk2, nil))
+}
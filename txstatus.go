@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
+)
+
+// TxState is the state of a tx in the ledger
+type TxState uint8
+
+const (
+	// TxPending is the state of a tx known to the ledger that is not in any of
+	// its blocks i.e. a tx restored from a snapshot
+	TxPending TxState = iota
+	// TxAppended is the state of a tx in an appended block that is not part
+	// of the main chain
+	TxAppended
+	// TxCommitted is the state of a tx in a main chain block
+	TxCommitted
+)
+
+// TxStatus is the state of a tx along with the block containing it
+type TxStatus struct {
+	State TxState
+	// Block containing the tx, its height and the index of the tx in it.  These
+	// are not set for pending txs
+	Block  bcpb.Digest
+	Height uint32
+	Index  int32
+	// Number of main chain blocks from the block containing the tx up to and
+	// including the last block.  Zero unless the tx is committed
+	Confirmations uint32
+}
+
+// TxStatus returns the status of the tx by the given digest.  Committed txs are
+// looked up in the location index.  Otherwise the branches not part of the main
+// chain are searched for the tx.  stores.ErrTxNotFound is returned if the tx
+// is not known to the ledger.  The blocks of the txs restored from a snapshot,
+// other than those of the snapshot block, are not known so they are reported
+// as pending.  So are txs committed before the location index existed until
+// ReindexHeights is run
+func (bc *Blockchain) TxStatus(digest bcpb.Digest) (*TxStatus, error) {
+	loc, err := bc.tx.tx.Location(digest)
+	if err == nil {
+		_, last := bc.blk.st.Last()
+		if last == nil {
+			return nil, stores.ErrBlockNotFound
+		}
+
+		return &TxStatus{
+			State:         TxCommitted,
+			Block:         loc.Block,
+			Height:        loc.Height,
+			Index:         loc.Index,
+			Confirmations: last.Height() - loc.Height + 1,
+		}, nil
+	}
+	if err != stores.ErrLocationNotFound {
+		return nil, err
+	}
+
+	// Txs are stored when their block is appended
+	if _, err = bc.tx.tx.Get(digest); err != nil && err != stores.ErrPruned {
+		return nil, err
+	}
+
+	for _, tip := range bc.blk.st.Tips() {
+		id, blk := tip, bc.blk.get(tip)
+		for blk != nil && !bc.onMainChain(id, blk) {
+			for i, tid := range blk.Txs {
+				if tid.Equal(digest) {
+					return &TxStatus{
+						State:  TxAppended,
+						Block:  id,
+						Height: blk.Height(),
+						Index:  int32(i),
+					}, nil
+				}
+			}
+
+			id = blk.Header.PrevBlock
+			blk = bc.blk.get(id)
+		}
+	}
+
+	return &TxStatus{State: TxPending}, nil
+}
+
+// onMainChain returns true if the block is the main chain block at its height
+func (bc *Blockchain) onMainChain(id bcpb.Digest, blk *bcpb.Block) bool {
+	main, err := bc.blk.st.GetByHeight(blk.Height())
+	return err == nil && main.Digest.Equal(id)
+}
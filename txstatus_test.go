@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/stores"
+)
+
+func Test_Blockchain_TxStatus(t *testing.T) {
+	conf := testBlockchainConfPrefix("txstatus/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "ts:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	atxs := []*bcpb.Tx{testUpdateTx(t, bc, "ts:a", "1"), testBaseTx(bc, "ts:b")}
+	a1 := testSignedBlock(bc, genesis, atxs, kp)
+	aid, err := bc.Append(a1, atxs)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.Commit(aid))
+
+	st, err := bc.TxStatus(gtxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxCommitted, st.State)
+	assert.Equal(t, genesis.Digest, st.Block)
+	assert.Equal(t, uint32(2), st.Confirmations)
+
+	st, err = bc.TxStatus(atxs[1].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, &TxStatus{State: TxCommitted, Block: aid, Height: 1, Index: 1, Confirmations: 1}, st)
+
+	// Competing block that is appended but not committed
+	btxs := []*bcpb.Tx{testBaseTx(bc, "ts:c")}
+	b1 := testSignedBlock(bc, genesis, btxs, kp)
+	bid, err := bc.Append(b1, btxs)
+	assert.Nil(t, err)
+
+	st, err = bc.TxStatus(btxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, &TxStatus{State: TxAppended, Block: bid, Height: 1}, st)
+
+	_, err = bc.TxStatus(testBaseTx(bc, "ts:d").Digest)
+	assert.Equal(t, stores.ErrTxNotFound, err)
+
+	// Switching branches moves the txs of the old branch back to appended
+	assert.Nil(t, bc.Reorg(bid))
+
+	st, err = bc.TxStatus(atxs[1].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, &TxStatus{State: TxAppended, Block: aid, Height: 1, Index: 1}, st)
+
+	st, err = bc.TxStatus(btxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxCommitted, st.State)
+	assert.Equal(t, bid, st.Block)
+	assert.Equal(t, uint32(1), st.Confirmations)
+
+	// Locations missing from the index are rebuilt along with the heights
+	for _, tid := range []bcpb.Digest{gtxs[0].Digest, btxs[0].Digest} {
+		assert.Nil(t, conf.TxStorage.RemoveLocation(nil, tid))
+	}
+	st, err = bc.TxStatus(gtxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxPending, st.State)
+
+	assert.Nil(t, bc.ReindexHeights())
+	st, err = bc.TxStatus(gtxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, &TxStatus{State: TxCommitted, Block: genesis.Digest, Height: 0, Index: 0, Confirmations: 2}, st)
+	st, err = bc.TxStatus(btxs[0].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, &TxStatus{State: TxCommitted, Block: bid, Height: 1, Index: 0, Confirmations: 1}, st)
+
+	// Txs of branches not part of the main chain are not indexed
+	st, err = bc.TxStatus(atxs[1].Digest)
+	assert.Nil(t, err)
+	assert.Equal(t, TxAppended, st.State)
+}
@@ -4,88 +4,27 @@ import (
 	"errors"
 
 	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/stores"
 )
 
+var errTxSpent = errors.New("tx already spent")
+
 // TxStore adds ledger logic around the store
 type txStore struct {
-	tx  TxStorage
-	dki DataKeyIndex
+	tx   TxStorage
+	dki  DataKeyIndex
+	utxo UTXOIndex
+	qi   QueryIndex
 }
 
-// SetBatch validates the transaction are not spent before setting them to the
-// store
-func (st *txStore) SetBatch(txs []*bcpb.Tx) error {
-	unspent := st.FindUnspent()
-
-	for _, tx := range txs {
-
-		// Make sure inputs haven't been spent
-		for _, in := range tx.Inputs {
-			if in.IsBase() {
-				continue
-			}
-
-			_, ok := unspent[in.Ref.String()]
-			if !ok {
-				return errors.New("tx already spent")
-			}
-
-		}
+// SetBatch validates the inputs of the transactions are unspent in the view
+// before setting them to the store as part of the batch
+func (st *txStore) SetBatch(batch stores.Batch, view *ledgerView, txs []*bcpb.Tx) error {
+	if err := view.checkUnspent(txs); err != nil {
+		return err
 	}
 
-	return st.tx.SetBatch(txs)
-}
-
-// FindUTX finds transaction with unused outputs for the given public key
-func (st *txStore) FindUTX(pubkey bcpb.PublicKey) map[string]bcpb.Tx {
-	// Get all unspent
-	unspent := st.FindUnspent()
-	// Filter by public key
-	for k, v := range unspent {
-		for _, out := range v.Outputs {
-			if !out.HasPublicKey(pubkey) {
-				delete(unspent, k)
-				break
-			}
-		}
-	}
-
-	return unspent
-}
-
-// FindUnspent finds all transactions whose outputs are not references by any
-// inputs
-func (st *txStore) FindUnspent() map[string]bcpb.Tx {
-	unspent := make(map[string]bcpb.Tx)
-	spent := make(map[string]struct{})
-
-	st.tx.Iter(func(tx bcpb.Tx) error {
-		// Check if its already marked as spent
-		if _, ok := spent[tx.Digest.String()]; !ok {
-			// Mark as unspent
-			unspent[tx.Digest.String()] = tx
-		}
-
-		if tx.IsBase() {
-			return nil
-		}
-
-		// Get through each input and remove any referenced tx
-		for _, in := range tx.Inputs {
-			tid := in.Ref.String()
-			// Mark referenced tx as spent
-			spent[tid] = struct{}{}
-
-			// Remove from unspent in case it marked so
-			if _, ok := unspent[tid]; ok {
-				delete(unspent, tid)
-			}
-		}
-
-		return nil
-	})
-
-	return unspent
+	return st.tx.SetBatch(batch, txs)
 }
 
 // GetDataKeyTx returns the last transaction and output index associated to the
@@ -121,16 +60,3 @@ func (st *txStore) NewTxInput(key bcpb.DataKey) (*bcpb.TxInput, error) {
 
 	return txi, err
 }
-
-func (st *txStore) indexTxsOutputs(txs []*bcpb.Tx) error {
-	for _, tx := range txs {
-		for i, txo := range tx.Outputs {
-			err := st.dki.Set(txo.DataKey, tx.Digest, int32(i))
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
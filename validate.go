@@ -8,65 +8,60 @@ import (
 	"github.com/hexablock/blockchain/keypair"
 )
 
-// validate block and associated transactions
-func (bc *Blockchain) validateBlock(blk *bcpb.Block, txs []*bcpb.Tx) error {
+var errTxNotInBlock = errors.New("tx not in block")
+
+// validate block and associated transactions against the state of the branch
+// it extends returning the number of valid block signatures
+func (bc *Blockchain) validateBlock(blk *bcpb.Block, txs []*bcpb.Tx, view *ledgerView) (int32, error) {
 	// Call the user specified block verifier/validator
 	err := bc.bv(blk.Header)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// Verify required signatures
-	if !bc.verifyBlockSignatures(blk) {
-		return bcpb.ErrSignatureVerificationFailed
+	sc, ok := bc.verifyBlockSignatures(blk)
+	if !ok {
+		return 0, bcpb.ErrSignatureVerificationFailed
+	}
+
+	// Check the header commits to the txs in the block
+	root, err := bcpb.Digests(blk.Txs).Root()
+	if err != nil {
+		return 0, err
+	}
+	if !root.Equal(blk.Header.Root) {
+		return 0, errRootMismatch
 	}
 
 	// Check txs exist in the block
+	if len(txs) != len(blk.Txs) {
+		return 0, errTxNotInBlock
+	}
 	for i, tid := range blk.Txs {
 		if !tid.Equal(txs[i].Digest) {
-			return errors.New("tx not in block")
+			return 0, errTxNotInBlock
 		}
 	}
 
-	err = bc.validateTxs(txs)
-	if err != nil {
-		return err
-	}
-
-	// Store transactions
-	return bc.tx.SetBatch(txs)
+	return sc, bc.validateTxs(txs, blockContext(blk), view)
 }
 
-// this must be called after the block header has been validated
-func (bc *Blockchain) verifyBlockSignatures(blk *bcpb.Block) bool {
-	var (
-		sh = blk.Header.Hash(bc.h)
-		sc int32
-	)
+// this must be called after the block header has been validated.  It returns
+// the number of valid signatures and whether they satisfy S
+func (bc *Blockchain) verifyBlockSignatures(blk *bcpb.Block) (int32, bool) {
+	sh := blk.Header.Hash(bc.h)
+	sc := keypair.CountSignatures(bc.curve, bc.h, sh, blk.Header.Signers, blk.Signatures)
 
-	for i := range blk.Header.Signers {
-		// Skip unsigned slots
-		if len(blk.Signatures[i]) == 0 {
-			continue
-		}
-
-		kp := keypair.New(bc.curve, bc.h)
-		kp.PublicKey = bcpb.PublicKey(blk.Header.Signers[i])
-		if kp.VerifySignature(sh, blk.Signatures[i]) {
-			sc++
-		}
-
-	}
-
-	return sc >= blk.Header.S
+	return sc, sc >= blk.Header.S
 }
 
-func (bc *Blockchain) validateTxs(txs []*bcpb.Tx) error {
+func (bc *Blockchain) validateTxs(txs []*bcpb.Tx, ctx *ChainContext, view *ledgerView) error {
 	var err error
 
 	// Validate each tx
 	for _, tx := range txs {
-		err = bc.validateTx(tx)
+		err = bc.validateTx(tx, ctx, view)
 		if err != nil {
 			break
 		}
@@ -75,15 +70,27 @@ func (bc *Blockchain) validateTxs(txs []*bcpb.Tx) error {
 	return err
 }
 
-func (bc *Blockchain) validateTx(tx *bcpb.Tx) error {
+// ValidateTx validates the tx against the current ledger state using the same
+// rules applied to the txs of a block being appended.  Output logic is
+// evaluated against the context returned by chainContext as the block the tx
+// will be included in is not yet known
+func (bc *Blockchain) ValidateTx(tx *bcpb.Tx) error {
+	view := newLedgerView(bc.tx)
+	if err := bc.validateTx(tx, bc.chainContext(), view); err != nil {
+		return err
+	}
+	return view.checkUnspent([]*bcpb.Tx{tx})
+}
+
+func (bc *Blockchain) validateTx(tx *bcpb.Tx, ctx *ChainContext, view *ledgerView) error {
 	// Validate each tx input
 	for _, in := range tx.Inputs {
 		var err error
 
 		if in.IsBase() {
-			err = bc.validateBaseTxInput(in)
+			err = bc.validateBaseTxInput(in, view)
 		} else {
-			_, err = bc.validateRegTxInput(in)
+			_, err = bc.validateRegTxInput(in, ctx)
 		}
 
 		if err != nil {
@@ -92,40 +99,63 @@ func (bc *Blockchain) validateTx(tx *bcpb.Tx) error {
 
 	}
 
-	return nil
+	return bc.validateSignerSetTx(tx, view)
 }
 
-// assume second arg in base is the data key
-func (bc *Blockchain) validateBaseTxInput(txi *bcpb.TxInput) error {
-	args := txi.Args()
-	if len(args) < 2 {
+func (bc *Blockchain) validateBaseTxInput(txi *bcpb.TxInput, view *ledgerView) error {
+	key := baseTxDataKey(txi)
+	if key == nil {
 		return nil
 	}
 
-	key := bcpb.DataKey(args[1])
-
 	// Make sure data key doesn't already exist
-	_, _, err := bc.tx.dki.Get(key)
-	if err == nil {
-		return fmt.Errorf("data key exists: %q", key)
+	cur, err := view.dataKey(key)
+	if err != nil {
+		return err
+	}
+	if cur.Ref != nil && !cur.Deleted() {
+		return errDataKeyExists(key)
 	}
 
 	return nil
 }
 
+// baseTxDataKey returns the DataKey created by the base input or nil if it
+// names none.  The second arg of a base input is assumed to be the data key
+func baseTxDataKey(txi *bcpb.TxInput) bcpb.DataKey {
+	args := txi.Args()
+	if len(args) < 2 {
+		return nil
+	}
+	return bcpb.DataKey(args[1])
+}
+
+func errDataKeyExists(key bcpb.DataKey) error {
+	return fmt.Errorf("data key exists: %q", key)
+}
+
 // validateTxInput validates the txinput including access authorization and
-// signature verification
-func (bc *Blockchain) validateRegTxInput(txi *bcpb.TxInput) (*bcpb.TxOutput, error) {
+// signature verification.  Output logic is evaluated against the given context
+func (bc *Blockchain) validateRegTxInput(txi *bcpb.TxInput, ctx *ChainContext) (*bcpb.TxOutput, error) {
 
 	txref, err := bc.tx.Get(txi.Ref)
 	if err != nil {
 		return nil, err
 	}
 
+	if txi.Index < 0 || int(txi.Index) >= len(txref.Outputs) {
+		return nil, errInvalidOutputIndex
+	}
+	if len(txi.Signatures) < len(txi.PubKeys) {
+		return nil, errRequiresMoreSignatures
+	}
+
 	var (
 		txo    = txref.Outputs[txi.Index]
 		digest = txi.Hash(bc.h)
-		sc     uint8
+		// Keys with a valid signature
+		signers = make([]bcpb.PublicKey, 0, len(txi.PubKeys))
+		seen    = make(map[string]struct{}, len(txi.PubKeys))
 	)
 
 	// Validate and get number of signatures. This is validated regardless of
@@ -136,11 +166,17 @@ func (bc *Blockchain) validateRegTxInput(txi *bcpb.TxInput) (*bcpb.TxOutput, err
 			return nil, bcpb.ErrNotAuthorized
 		}
 
+		// A key listed more than once only counts once
+		if _, ok := seen[string(pk)]; ok {
+			continue
+		}
+		seen[string(pk)] = struct{}{}
+
 		// Verify tx input signatures
 		kp := keypair.New(bc.curve, bc.h)
 		kp.PublicKey = pk
 		if kp.VerifySignature(digest, txi.Signatures[i]) {
-			sc++
+			signers = append(signers, pk)
 		}
 
 	}
@@ -153,9 +189,14 @@ func (bc *Blockchain) validateRegTxInput(txi *bcpb.TxInput) (*bcpb.TxOutput, err
 	reqSigs := uint8(txo.Logic[0])
 
 	// Check required signatures.
-	if sc < reqSigs {
+	if len(signers) < int(reqSigs) {
 		return nil, errRequiresMoreSignatures
 	}
 
+	// Any remaining logic is evaluated based on its tag
+	if err = bc.evaluateLogic(txo, txi, signers, ctx); err != nil {
+		return nil, err
+	}
+
 	return txo, nil
 }
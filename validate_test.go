@@ -0,0 +1,149 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hexablock/blockchain/bcpb"
+	"github.com/hexablock/blockchain/keypair"
+	"github.com/hexablock/blockchain/script"
+)
+
+func Test_Blockchain_ScriptLogic(t *testing.T) {
+	conf := testBlockchainConfPrefix("script/")
+	bc := New(conf)
+
+	kps := make([]*keypair.KeyPair, 3)
+	for i := range kps {
+		kps[i], _ = keypair.Generate(conf.Curve, conf.Hasher)
+	}
+
+	hf := conf.Hasher.New()
+	hf.Write([]byte("secret"))
+
+	hashlock := script.NewBuilder().
+		AddArg(0).AddOp(script.OpHash).AddData(hf.Sum(nil)).AddOp(script.OpEqual).
+		Script()
+	multisig := script.NewBuilder().
+		AddInt(2).AddData(kps[0].PublicKey).AddData(kps[1].PublicKey).AddData(kps[2].PublicKey).
+		AddInt(3).AddOp(script.OpCheckSigs).
+		Script()
+	heightlock := script.NewBuilder().AddInt(5).AddOp(script.OpCheckHeightVerify).Script()
+
+	gtx := bcpb.NewBaseTx()
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("script:hash"), Logic: script.NewLogic(0, hashlock)})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("script:multi"), Logic: script.NewLogic(0, multisig)})
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("script:height"), Logic: script.NewLogic(0, heightlock)})
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kps[0])
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	spend := func(key string, args [][]byte, signers ...*keypair.KeyPair) error {
+		txi, err := bc.NewTxInput(bcpb.DataKey(key))
+		assert.Nil(t, err)
+		for _, kp := range signers {
+			txi.AddPubKey(kp.PublicKey)
+		}
+		txi.AddArgs(args...)
+
+		digest := txi.Hash(bc.Hasher())
+		for _, kp := range signers {
+			sig, _ := kp.Sign(digest)
+			txi.Sign(kp.PublicKey, sig)
+		}
+
+		tx := bcpb.NewTx()
+		tx.AddInput(txi)
+		tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey(key)})
+		tx.SetDigest(bc.Hasher())
+		return bc.ValidateTx(tx)
+	}
+
+	assert.Nil(t, spend("script:hash", [][]byte{[]byte("secret")}))
+	assert.Equal(t, script.ErrScriptFailed, spend("script:hash", [][]byte{[]byte("guess")}))
+	assert.Equal(t, script.ErrArgIndex, spend("script:hash", nil))
+
+	assert.Nil(t, spend("script:multi", nil, kps[0], kps[2]))
+	assert.Equal(t, script.ErrScriptFailed, spend("script:multi", nil, kps[1]))
+
+	assert.Equal(t, script.ErrLockTime, spend("script:height", nil))
+}
+
+func Test_Blockchain_ScriptTimeLock(t *testing.T) {
+	conf := testBlockchainConfPrefix("timelock/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	// Re-signs the block with the given timestamp
+	stamp := func(blk *bcpb.Block, ts int64) {
+		blk.Header.Timestamp = ts
+		blk.SetHash(bc.Hasher())
+		blk.Signatures[0] = nil
+		sig, _ := kp.Sign(blk.Digest)
+		blk.Sign(kp.PublicKey, sig)
+	}
+
+	timelock := script.NewBuilder().AddInt(1001).AddOp(script.OpCheckTimeVerify).Script()
+	gtx := bcpb.NewBaseTx()
+	gtx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("timelock:a"), Logic: script.NewLogic(0, timelock)})
+	gtx.SetDigest(bc.Hasher())
+
+	gtxs := []*bcpb.Tx{gtx}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	stamp(genesis, 1000)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	txi, err := bc.NewTxInput(bcpb.DataKey("timelock:a"))
+	assert.Nil(t, err)
+	tx := bcpb.NewTx()
+	tx.AddInput(txi)
+	tx.AddOutput(&bcpb.TxOutput{DataKey: bcpb.DataKey("timelock:a")})
+	tx.SetDigest(bc.Hasher())
+
+	// Outside of a block the lock is checked against the last block
+	assert.Equal(t, script.ErrLockTime, bc.ValidateTx(tx))
+
+	// In a block it is checked against that block
+	txs := []*bcpb.Tx{tx}
+	early := testSignedBlock(bc, genesis, txs, kp)
+	stamp(early, 1000)
+	_, err = bc.Append(early, txs)
+	assert.Equal(t, script.ErrLockTime, err)
+
+	blk := testSignedBlock(bc, genesis, txs, kp)
+	stamp(blk, 1001)
+	_, err = bc.Append(blk, txs)
+	assert.Nil(t, err)
+}
+
+func Test_Blockchain_AppendMalformed(t *testing.T) {
+	conf := testBlockchainConfPrefix("malformed/")
+	bc := New(conf)
+
+	kp, _ := keypair.Generate(conf.Curve, conf.Hasher)
+
+	gtxs := []*bcpb.Tx{testBaseTx(bc, "malformed:a")}
+	genesis := testSignedBlock(bc, nil, gtxs, kp)
+	assert.Nil(t, bc.SetGenesis(genesis, gtxs))
+	assert.Nil(t, bc.Commit(genesis.Digest))
+
+	// Tx digest without an algorithm
+	tx := testBaseTx(bc, "malformed:b")
+	tx.Digest = bcpb.Digest("nodelimiter")
+	txs := []*bcpb.Tx{tx}
+	blk := testSignedBlock(bc, genesis, txs, kp)
+	_, err := bc.Append(blk, txs)
+	assert.Equal(t, bcpb.ErrInvalidLeaf, err)
+
+	// Fewer txs than the block holds
+	txs = []*bcpb.Tx{testBaseTx(bc, "malformed:c")}
+	blk = testSignedBlock(bc, genesis, txs, kp)
+	_, err = bc.Append(blk, nil)
+	assert.Equal(t, errTxNotInBlock, err)
+}